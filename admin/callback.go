@@ -0,0 +1,347 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+	"github.com/goft-cloud/go-xxl-job-client/v2/metrics"
+	"github.com/goft-cloud/go-xxl-job-client/v2/transport"
+)
+
+const (
+	callbackQueueLen  = 1024
+	callbackBatchSize = 100
+	// callbackMaxRetry max retry times for send callbacks, then will persist them to file.
+	callbackMaxRetry = 3
+	// callbackRetryDelay the first retry delay, will double it on each retry.
+	callbackRetryDelay = time.Second
+	// callbackReplayInterval interval for replay the persisted callback files.
+	callbackReplayInterval = 30 * time.Second
+
+	// CallbackFailDir dir name for save the undelivered callbacks. in the LogBasePath
+	CallbackFailDir    = "callbacklog"
+	callbackFilePrefix = "xxl-job-callback-"
+)
+
+// CallbackMaxFiles max number of the persisted callback files, the oldest files will be removed on exceeded.
+var CallbackMaxFiles = 1000
+
+// CallbackQueue buffer the job callback params, batch send them to xxl-job admin.
+//
+// the undelivered callbacks will be persisted to files under LogBasePath,
+// and replay them periodically(also after executor restart).
+type CallbackQueue struct {
+	mu sync.RWMutex
+	// send callback params to admin, return false on all admin address are failed.
+	send func(params []*transport.HandleCallbackParam) bool
+	ch   chan *transport.HandleCallbackParam
+
+	started bool
+	closed  bool
+	stopCh  chan struct{}
+	wg      sync.WaitGroup
+	// file seq for avoid file name conflict
+	fileSeq uint32
+}
+
+// NewCallbackQueue create
+func NewCallbackQueue(send func(params []*transport.HandleCallbackParam) bool) *CallbackQueue {
+	return &CallbackQueue{
+		send:   send,
+		ch:     make(chan *transport.HandleCallbackParam, callbackQueueLen),
+		stopCh: make(chan struct{}),
+	}
+}
+
+// Start the send and replay loops
+func (q *CallbackQueue) Start() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.started || q.closed {
+		return
+	}
+
+	q.started = true
+	q.wg.Add(2)
+	go q.sendLoop()
+	go q.replayLoop()
+}
+
+// Push callback params to queue. it does not block on the queue is full,
+// the overflowed params will be persisted to file and replay later.
+//
+// if the queue is not started or is stopped, will send them directly.
+func (q *CallbackQueue) Push(params ...*transport.HandleCallbackParam) {
+	q.mu.RLock()
+	if !q.started || q.closed {
+		q.mu.RUnlock()
+		q.deliver(params, false)
+		return
+	}
+
+	var overflow []*transport.HandleCallbackParam
+	for _, p := range params {
+		select {
+		case q.ch <- p:
+		default:
+			overflow = append(overflow, p)
+		}
+	}
+	q.mu.RUnlock()
+
+	if len(overflow) > 0 {
+		logger.Warn("the job callback queue is full, persist callbacks to file", "size", len(overflow), "logIds", callbackLogIds(overflow))
+		if err := q.persist(overflow); err != nil {
+			logger.Error("persist job callbacks to file failed", "error", err)
+		}
+	}
+}
+
+// Stop the queue, flush the buffered callbacks. will persist them on send failed.
+//
+// call it again can wait the flush completed, after the previous call is timeout.
+func (q *CallbackQueue) Stop(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.stopCh)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		logger.Error("flush job callbacks timeout, persist the pending callbacks", "error", ctx.Err())
+		q.persistPending()
+		return ctx.Err()
+	}
+}
+
+// persistPending persist the callback params left in queue to file, replay them after restart.
+func (q *CallbackQueue) persistPending() {
+	var pending []*transport.HandleCallbackParam
+loop:
+	for {
+		select {
+		case p := <-q.ch:
+			pending = append(pending, p)
+		default:
+			break loop
+		}
+	}
+
+	if len(pending) == 0 {
+		return
+	}
+	if err := q.persist(pending); err != nil {
+		logger.Error("persist job callbacks to file failed", "error", err)
+	}
+}
+
+func (q *CallbackQueue) sendLoop() {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case p := <-q.ch:
+			q.deliver(q.collect(p), true)
+		case <-q.stopCh:
+			q.flush()
+			return
+		}
+	}
+}
+
+// collect a batch of callback params from queue
+func (q *CallbackQueue) collect(first *transport.HandleCallbackParam) []*transport.HandleCallbackParam {
+	batch := []*transport.HandleCallbackParam{first}
+	for len(batch) < callbackBatchSize {
+		select {
+		case p := <-q.ch:
+			batch = append(batch, p)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// flush all buffered callback params on stop
+func (q *CallbackQueue) flush() {
+	for {
+		select {
+		case p := <-q.ch:
+			q.deliver(q.collect(p), false)
+		default:
+			return
+		}
+	}
+}
+
+// deliver callback params to admin, retry with backoff on failed.
+// will persist them to file on all retries are failed.
+func (q *CallbackQueue) deliver(params []*transport.HandleCallbackParam, retry bool) {
+	if len(params) == 0 {
+		return
+	}
+
+	if q.send(params) || retry && q.retrySend(params) {
+		metrics.Add(metrics.CallbacksTotal, metrics.Labels{"status": metrics.StatusSuccess}, float64(len(params)))
+		return
+	}
+
+	metrics.Add(metrics.CallbacksTotal, metrics.Labels{"status": metrics.StatusFail}, float64(len(params)))
+	logger.Error("job callback failed, persist callbacks to file for retry later", "size", len(params), "logIds", callbackLogIds(params))
+	if err := q.persist(params); err != nil {
+		logger.Error("persist job callbacks to file failed", "error", err)
+	}
+}
+
+// retrySend retry send callback params with backoff
+func (q *CallbackQueue) retrySend(params []*transport.HandleCallbackParam) bool {
+	delay := callbackRetryDelay
+	for i := 1; i <= callbackMaxRetry; i++ {
+		logger.Warn("job callback failed, will retry later", "retry", i, "maxRetry", callbackMaxRetry, "delay", delay)
+
+		select {
+		case <-q.stopCh: // stopping, dont wait retry
+			return false
+		case <-time.After(delay):
+		}
+
+		if q.send(params) {
+			return true
+		}
+		delay *= 2
+	}
+	return false
+}
+
+func (q *CallbackQueue) replayLoop() {
+	defer q.wg.Done()
+
+	// replay undelivered callbacks on start.
+	q.replay()
+
+	t := time.NewTicker(callbackReplayInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-q.stopCh:
+			return
+		case <-t.C:
+			q.replay()
+		}
+	}
+}
+
+// replay the persisted callback files
+func (q *CallbackQueue) replay() {
+	files, err := filepath.Glob(callbackFailPath() + "/" + callbackFilePrefix + "*.log")
+	if err != nil || len(files) == 0 {
+		return
+	}
+
+	for _, file := range files {
+		bs, err := ioutil.ReadFile(file)
+		if err != nil {
+			logger.Error("read job callback file failed", "file", file, "error", err)
+			continue
+		}
+
+		// remove it. if send failed, will persist to new file.
+		if err = os.Remove(file); err != nil {
+			logger.Error("remove job callback file failed", "file", file, "error", err)
+			continue
+		}
+
+		var params []*transport.HandleCallbackParam
+		if err = json.Unmarshal(bs, &params); err != nil {
+			logger.Error("decode job callback file failed", "file", file, "error", err)
+			continue
+		}
+
+		logger.Info("replay job callbacks from file", "size", len(params), "file", file)
+		q.deliver(params, false)
+	}
+}
+
+// persist callback params to file
+func (q *CallbackQueue) persist(params []*transport.HandleCallbackParam) error {
+	bs, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	dir := callbackFailPath()
+	if err = os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	seq := atomic.AddUint32(&q.fileSeq, 1)
+	name := fmt.Sprintf("%s%d-%d.log", callbackFilePrefix, time.Now().UnixNano(), seq)
+
+	// write to temp file, then rename. avoid replay a partially written file.
+	tmpFile := dir + "/." + name
+	if err = ioutil.WriteFile(tmpFile, bs, 0644); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpFile, dir+"/"+name); err != nil {
+		return err
+	}
+
+	q.trimFiles()
+	return nil
+}
+
+// trimFiles remove the oldest callback files on exceeded CallbackMaxFiles
+func (q *CallbackQueue) trimFiles() {
+	if CallbackMaxFiles <= 0 {
+		return
+	}
+
+	// the file name contains the create time, sorted by name is sorted by time
+	files, err := filepath.Glob(callbackFailPath() + "/" + callbackFilePrefix + "*.log")
+	if err != nil || len(files) <= CallbackMaxFiles {
+		return
+	}
+
+	for _, file := range files[:len(files)-CallbackMaxFiles] {
+		logger.Error("too many job callback files, discard the oldest", "file", file, "maxFiles", CallbackMaxFiles)
+		if err = os.Remove(file); err != nil {
+			logger.Error("remove job callback file failed", "file", file, "error", err)
+		}
+	}
+}
+
+// callbackLogIds collect logIds of the callback params, use for logs
+func callbackLogIds(params []*transport.HandleCallbackParam) []int64 {
+	logIds := make([]int64, 0, len(params))
+	for _, p := range params {
+		logIds = append(logIds, p.LogId)
+	}
+	return logIds
+}
+
+// callbackFailPath dir for save the undelivered callbacks
+func callbackFailPath() string {
+	return strings.TrimRight(logger.LogBasePath(), "/") + "/" + CallbackFailDir
+}
@@ -0,0 +1,135 @@
+package admin_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/admin"
+	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+	"github.com/goft-cloud/go-xxl-job-client/v2/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallbackQueue_persistAndReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xxl-job-callback")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	logger.SetLogBasePath(dir)
+
+	var online, sent int32
+	send := func(params []*transport.HandleCallbackParam) bool {
+		if atomic.LoadInt32(&online) == 0 {
+			return false
+		}
+		atomic.AddInt32(&sent, int32(len(params)))
+		return true
+	}
+
+	// admin is offline, callbacks will be persisted on stop.
+	q := admin.NewCallbackQueue(send)
+	q.Start()
+	q.Push(&transport.HandleCallbackParam{LogId: 1}, &transport.HandleCallbackParam{LogId: 2})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	assert.NoError(t, q.Stop(ctx))
+
+	files, _ := filepath.Glob(dir + "/" + admin.CallbackFailDir + "/*.log")
+	assert.Len(t, files, 1)
+
+	// admin is online, replay callbacks on start.
+	atomic.StoreInt32(&online, 1)
+	q = admin.NewCallbackQueue(send)
+	q.Start()
+	assert.NoError(t, q.Stop(ctx))
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&sent))
+	files, _ = filepath.Glob(dir + "/" + admin.CallbackFailDir + "/*.log")
+	assert.Len(t, files, 0)
+}
+
+func TestCallbackQueue_Push_full(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xxl-job-callback")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	logger.SetLogBasePath(dir)
+
+	maxFiles := admin.CallbackMaxFiles
+	admin.CallbackMaxFiles = 5
+	defer func() {
+		admin.CallbackMaxFiles = maxFiles
+	}()
+
+	// admin is blocked
+	unblock := make(chan struct{})
+	q := admin.NewCallbackQueue(func(params []*transport.HandleCallbackParam) bool {
+		<-unblock
+		return true
+	})
+	q.Start()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 2000; i++ {
+			q.Push(&transport.HandleCallbackParam{LogId: int64(i)})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("push is blocked on the queue is full")
+	}
+
+	// the overflowed callbacks are persisted, and the files are capped
+	files, _ := filepath.Glob(dir + "/" + admin.CallbackFailDir + "/*.log")
+	assert.Len(t, files, 5)
+
+	close(unblock)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	assert.NoError(t, q.Stop(ctx))
+}
+
+func TestCallbackQueue_Stop_timeout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xxl-job-callback")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	logger.SetLogBasePath(dir)
+
+	// admin is blocked on the first callback
+	sending := make(chan struct{}, 1)
+	unblock := make(chan struct{})
+	q := admin.NewCallbackQueue(func(params []*transport.HandleCallbackParam) bool {
+		sending <- struct{}{}
+		<-unblock
+		return true
+	})
+	q.Start()
+	q.Push(&transport.HandleCallbackParam{LogId: 1})
+	<-sending
+	q.Push(&transport.HandleCallbackParam{LogId: 2}, &transport.HandleCallbackParam{LogId: 3})
+
+	// the pending callbacks are persisted on stop timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Error(t, q.Stop(ctx))
+	close(unblock)
+	assert.NoError(t, q.Stop(context.Background()))
+
+	files, _ := filepath.Glob(dir + "/" + admin.CallbackFailDir + "/*.log")
+	if assert.Len(t, files, 1) {
+		bs, err := ioutil.ReadFile(files[0])
+		assert.NoError(t, err)
+		var params []*transport.HandleCallbackParam
+		assert.NoError(t, json.Unmarshal(bs, &params))
+		assert.Len(t, params, 2)
+	}
+}
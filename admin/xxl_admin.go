@@ -1,12 +1,15 @@
 package admin
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/goft-cloud/go-xxl-job-client/v2/executor"
 	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+	"github.com/goft-cloud/go-xxl-job-client/v2/metrics"
 	"github.com/goft-cloud/go-xxl-job-client/v2/transport"
 )
 
@@ -20,6 +23,11 @@ type XxlAdminServer struct {
 	Registry  *transport.RegistryParam
 	BeatTime  time.Duration
 	executor  *executor.Executor
+	// callbacks buffer the job callbacks, batch send them to admin
+	callbacks *CallbackQueue
+	// stop the auto register heartbeat
+	stopOnce sync.Once
+	stopCh   chan struct{}
 }
 
 const (
@@ -39,13 +47,14 @@ func NewAdminServer(addresses []string, timeout, beatTime time.Duration, executo
 	}
 
 	if beatTime >= renewTimeWaring {
-		logger.Info("Waring! Risk of your executor can't be renewed")
+		logger.Warn("Waring! Risk of your executor can't be renewed", "beatTime", beatTime)
 	}
 
 	s := &XxlAdminServer{
 		Timeout:  timeout,
 		BeatTime: beatTime,
 		executor: executor,
+		stopCh:   make(chan struct{}),
 	}
 
 	// addressMap := sync.Map{}
@@ -59,14 +68,18 @@ func NewAdminServer(addresses []string, timeout, beatTime time.Duration, executo
 		s.Addresses.Store(add, address)
 	}
 
+	s.callbacks = NewCallbackQueue(func(params []*transport.HandleCallbackParam) bool {
+		return s.requestAdminApi(s.apiCallback, params)
+	})
+
 	// s.Addresses = addressMap
 	return s
 }
 
 // RegisterExecutor to xxl-job admin
-func (s *XxlAdminServer) RegisterExecutor() {
+func (s *XxlAdminServer) RegisterExecutor() error {
 	if s.executor.AppName == "" {
-		panic("appName is executor name, it can't be null")
+		return errors.New("appName is executor name, it can't be null")
 	}
 
 	regAddr := s.executor.GetRegisterAddr()
@@ -79,22 +92,28 @@ func (s *XxlAdminServer) RegisterExecutor() {
 
 	hasValid := s.requestAdminApi(s.registerExe, s.Registry)
 	if !hasValid {
-		panic("register executor failed, please check xxl admin address OR accessToken")
-	} else {
-		logger.Infof("register the executor: %s (clientIP: %s) to admin OK", s.executor.AppName, regAddr)
+		return errors.New("register executor failed, please check xxl admin address OR accessToken")
 	}
+
+	logger.Info("register the executor to admin OK", "appName", s.executor.AppName, "clientAddr", regAddr)
+	return nil
 }
 
 func (s *XxlAdminServer) AutoRegisterJobGroup() {
 	s.Registry.RegistryValue = s.executor.GetRegisterAddr()
 	t := time.NewTicker(s.BeatTime)
 
+	defer t.Stop()
+
 	for {
 		select {
+		case <-s.stopCh:
+			logger.Debug("Heartbeat - stop auto register executor to admin")
+			return
 		case <-t.C:
 			res := s.requestAdminApi(s.registerExe, s.Registry)
 			if !res {
-				logger.Error("Heartbeat - ensure register executor to admin API server FAILED")
+				logger.Error("Heartbeat - ensure register executor to admin API server FAILED", "appName", s.executor.AppName)
 			} else {
 				logger.Debug("Heartbeat - ensure register executor to admin API server OK")
 			}
@@ -102,6 +121,13 @@ func (s *XxlAdminServer) AutoRegisterJobGroup() {
 	}
 }
 
+// StopAutoRegister stop the auto register heartbeat
+func (s *XxlAdminServer) StopAutoRegister() {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+}
+
 // UnregisterExecutor remove register executor
 func (s *XxlAdminServer) UnregisterExecutor() {
 	logger.Info("remove job executor from xxl-job admin")
@@ -110,11 +136,20 @@ func (s *XxlAdminServer) UnregisterExecutor() {
 }
 
 // CallbackAdmin 执行器执行完任务后，回调通知admin任务结果时使用
+//
+// the callbacks will be batch send by the callback queue, retry and persist them on failed.
 func (s *XxlAdminServer) CallbackAdmin(callbackParam []*transport.HandleCallbackParam) {
-	res := s.requestAdminApi(s.apiCallback, callbackParam)
-	if !res {
-		logger.Error("job callback failed")
-	}
+	s.callbacks.Push(callbackParam...)
+}
+
+// StartCallback start the callback queue
+func (s *XxlAdminServer) StartCallback() {
+	s.callbacks.Start()
+}
+
+// StopCallback stop the callback queue, flush the buffered callbacks.
+func (s *XxlAdminServer) StopCallback(ctx context.Context) error {
+	return s.callbacks.Stop(ctx)
 }
 
 // 使用有效地址请求，没有有效地址遍历调用
@@ -165,7 +200,9 @@ func (s *XxlAdminServer) requestAdminApi(op func(string, interface{}) bool, para
 	return reqSuccess
 }
 
-func (s *XxlAdminServer) registerExe(address string, param interface{}) bool {
+func (s *XxlAdminServer) registerExe(address string, param interface{}) (ok bool) {
+	defer observeApi("registry", address, time.Now(), &ok)
+
 	resMap, err := RegisterJobExecutor(address, s.AccessToken, param.(*transport.RegistryParam), s.Timeout)
 	if err == nil && resMap["code"].(float64) == http.StatusOK {
 		return true
@@ -174,7 +211,9 @@ func (s *XxlAdminServer) registerExe(address string, param interface{}) bool {
 	}
 }
 
-func (s *XxlAdminServer) removerRegister(address string, param interface{}) bool {
+func (s *XxlAdminServer) removerRegister(address string, param interface{}) (ok bool) {
+	defer observeApi("registryRemove", address, time.Now(), &ok)
+
 	resMap, err := RemoveJobExecutor(address, s.AccessToken, param.(*transport.RegistryParam), s.Timeout)
 	if err == nil && resMap["code"].(float64) == http.StatusOK {
 		return true
@@ -183,15 +222,31 @@ func (s *XxlAdminServer) removerRegister(address string, param interface{}) bool
 	}
 }
 
-func (s *XxlAdminServer) apiCallback(address string, param interface{}) bool {
+func (s *XxlAdminServer) apiCallback(address string, param interface{}) (ok bool) {
+	defer observeApi("callback", address, time.Now(), &ok)
+
 	resMap, err := ApiCallback(address, s.AccessToken, param.([]*transport.HandleCallbackParam), s.Timeout)
-	if err == nil && resMap["code"].(float64) == http.StatusOK {
+	if err != nil {
+		logger.Error("job callback to admin failed", "address", address, "error", err)
+		return false
+	}
+
+	if code, _ := resMap["code"].(float64); code == http.StatusOK {
 		return true
 	} else {
 		return false
 	}
 }
 
+// observeApi report the admin api request duration and failure to metrics
+func observeApi(api, address string, start time.Time, ok *bool) {
+	labels := metrics.Labels{"api": api, "address": address}
+	metrics.ObserveDuration(metrics.AdminRequestDuration, labels, start)
+	if !*ok {
+		metrics.Inc(metrics.AdminRequestFailures, labels)
+	}
+}
+
 func (s *XxlAdminServer) setAddressValid(address string, flag int) {
 	add, ok := s.Addresses.Load(address)
 	if ok {
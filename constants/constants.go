@@ -30,4 +30,31 @@ const (
 	EnvXxlShardIdx = "XXL_SHARD_IDX"
 	// EnvXxlShardTotal env name
 	EnvXxlShardTotal = "XXL_SHARD_TOTAL"
+	// EnvXxlJobId env name
+	EnvXxlJobId = "XXL_JOB_ID"
+	// EnvXxlLogId env name
+	EnvXxlLogId = "XXL_LOG_ID"
+	// EnvXxlJobParam env name, value is the full input param
+	EnvXxlJobParam = "XXL_JOB_PARAM"
+	// EnvXxlLogFile env name, value is the task log file path
+	EnvXxlLogFile = "XXL_LOG_FILE"
+)
+
+// job handle result codes, same as the xxl-job ReturnT/XxlJobContext codes
+const (
+	HandleCodeSuccess = 200
+	HandleCodeFail    = 500
+	HandleCodeTimeout = 502
+	// HandleCodeUnsupported the glue type is not supported by the executor
+	HandleCodeUnsupported = 501
+)
+
+// executor block strategies, same as the xxl-job ExecutorBlockStrategyEnum
+const (
+	// BlockSerialExecution run the new trigger after the running task(default)
+	BlockSerialExecution = "SERIAL_EXECUTION"
+	// BlockDiscardLater discard the new trigger on the job has running task
+	BlockDiscardLater = "DISCARD_LATER"
+	// BlockCoverEarly kill the running task and run the new trigger
+	BlockCoverEarly = "COVER_EARLY"
 )
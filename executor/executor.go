@@ -99,3 +99,18 @@ func (e *Executor) Run(taskSize int) {
 	}
 	e.gettyClient.Run(e.Port, taskSize)
 }
+
+// Start the executor client server, it does not block.
+func (e *Executor) Start(taskSize int) {
+	if e.gettyClient == nil {
+		panic("executor client has not been set")
+	}
+	e.gettyClient.Start(e.Port, taskSize)
+}
+
+// Close the executor client server
+func (e *Executor) Close() {
+	if e.gettyClient != nil {
+		e.gettyClient.Close()
+	}
+}
@@ -43,6 +43,8 @@ type GettyClient struct {
 	PkgHandler getty.ReadWriter
 
 	EventListener getty.EventListener
+
+	server getty.Server
 }
 
 // NewGettyClient create.
@@ -53,7 +55,22 @@ func NewGettyClient(pkgHandler getty.ReadWriter, eventListener getty.EventListen
 	}
 }
 
+// Run start the client server and wait close signals.
 func (c *GettyClient) Run(port, taskSize int) {
+	c.Start(port, taskSize)
+
+	WaitCloseSignals(func() {
+		logger.Info("client server closing ......")
+
+		c.Close()
+		if c.ServeCloserFn != nil {
+			c.ServeCloserFn()
+		}
+	})
+}
+
+// Start the client server, it does not block.
+func (c *GettyClient) Start(port, taskSize int) {
 	onceTaskPoll.Do(func() {
 		// gxsync.NewTaskPoolSimple()
 		taskPool = gxsync.NewTaskPool(
@@ -78,15 +95,15 @@ func (c *GettyClient) Run(port, taskSize int) {
 		return
 	})
 
-	// util.WaitCloseSignals(server)
-	waitCloseSignals(func() {
-		logger.Info("client server closing ......")
+	c.server = server
+}
 
-		server.Close()
-		if c.ServeCloserFn != nil {
-			c.ServeCloserFn()
-		}
-	})
+// Close the client server
+func (c *GettyClient) Close() {
+	if c.server != nil {
+		c.server.Close()
+		c.server = nil
+	}
 }
 
 func (c *GettyClient) initialSession(session getty.Session) (err error) {
@@ -128,10 +145,12 @@ func (c *GettyClient) initialSession(session getty.Session) (err error) {
 	return err
 }
 
-func waitCloseSignals(closer func()) {
+// WaitCloseSignals block until receive the OS close signals, then call the closer.
+func WaitCloseSignals(closer func()) {
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, os.Interrupt, os.Kill, syscall.SIGQUIT, syscall.SIGTERM, syscall.SIGINT)
 	<-signals
+	signal.Stop(signals)
 	// closer.Close()
 	closer()
 }
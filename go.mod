@@ -9,4 +9,6 @@ require (
 	github.com/gookit/color v1.5.0
 	github.com/gookit/goutil v0.4.4
 	github.com/stretchr/testify v1.7.0
+	golang.org/x/sys v0.0.0-20211117180635-dee7805ff2e1
+	gopkg.in/yaml.v3 v3.0.1
 )
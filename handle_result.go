@@ -0,0 +1,43 @@
+package xxl
+
+import (
+	"context"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/constants"
+	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+)
+
+// HandleSuccess set the task success message, it will be sent to admin on callback.
+// like the XxlJobHelper.handleSuccess() in java.
+//
+// Usage:
+//
+//	xxl.HandleSuccess(ctx, fmt.Sprintf("processed %d rows", n))
+//	return nil
+func HandleSuccess(ctx context.Context, msg string) bool {
+	return HandleResult(ctx, constants.HandleCodeSuccess, msg)
+}
+
+// HandleFail mark the task failed with message, even if the handler returns nil.
+func HandleFail(ctx context.Context, msg string) bool {
+	return HandleResult(ctx, constants.HandleCodeFail, msg)
+}
+
+// HandleTimeout mark the task timeout with message.
+func HandleTimeout(ctx context.Context, msg string) bool {
+	return HandleResult(ctx, constants.HandleCodeTimeout, msg)
+}
+
+// HandleResult set the task handle result code and message, the message will be written to the task log.
+//
+// the error returned by handler takes precedence over the result. return false on ctx is not a job ctx.
+func HandleResult(ctx context.Context, code int32, msg string) bool {
+	obj, err := GetParamObj(ctx)
+	if err != nil || obj.Result == nil {
+		return false
+	}
+
+	obj.Result.Set(code, msg)
+	logger.LogJobf(ctx, "handle result: code=%d, msg: %s", code, msg)
+	return true
+}
@@ -4,14 +4,20 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/goft-cloud/go-xxl-job-client/v2/constants"
 	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+	"github.com/goft-cloud/go-xxl-job-client/v2/param"
+	"github.com/goft-cloud/go-xxl-job-client/v2/tracing"
 	"github.com/goft-cloud/go-xxl-job-client/v2/transport"
 	"github.com/gookit/goutil"
-	"github.com/gookit/goutil/strutil"
 )
 
+// BeanTimeoutGrace max wait time for the handler return after the task timeout.
+// the handler should return on the ctx done.
+var BeanTimeoutGrace = 500 * time.Millisecond
+
 // BeanJobRunner interface
 type BeanJobRunner interface {
 	Handle(ctx context.Context) error
@@ -28,45 +34,68 @@ func (bf BeanJobRunFunc) Handle(ctx context.Context) error {
 // BeanHandler struct
 type BeanHandler struct {
 	RunFunc BeanJobRunFunc
+	// Tag the JobTag of the tasks, default is the RunFunc name.
+	Tag string
+	// Middlewares wrap the RunFunc on execute. the first middleware is the outermost.
+	Middlewares []BeanJobMiddleware
+	// ParamFormat the executor params format. default will detect it by content.
+	ParamFormat param.Format
 }
 
 // ParseJob info
 func (b *BeanHandler) ParseJob(trigger *transport.TriggerParam) (jrp *JobRunParam, err error) {
 	if b.RunFunc == nil {
-		logger.Errorf("the bean job#%d handler func not registered", trigger.JobId, trigger.LogId)
+		logger.Error("the bean job handler func not registered", "jobId", trigger.JobId, "logId", trigger.LogId)
 		return nil, errors.New("job run function not found")
 	}
 
 	inputParam := make(map[string]string)
-	// ensure 'fullParam' key always exists.
-	inputParam["fullParam"] = trigger.ExecutorParams
+	format := b.ParamFormat
+	if format == param.FormatAuto {
+		format = param.DetectFormat(trigger.ExecutorParams)
+	}
 
-	if trigger.ExecutorParams != "" {
-		params := strings.Split(trigger.ExecutorParams, "\n")
-
-		if len(params) > 0 {
-			for _, param := range params {
-				// skip empty. if start with #, as comments
-				if param == "" && param[0] == '#' {
-					continue
-				}
-
-				// jobP := strings.SplitN(param, "=", 2)
-				jobP := strutil.SplitNTrimmed(param, "=", 2)
-				if len(jobP) > 1 {
-					inputParam[jobP[0]] = jobP[1]
-				}
-			}
+	var (
+		inputData   map[string]interface{}
+		inputValues param.Values
+	)
+	if format == param.FormatJSON || format == param.FormatYAML {
+		inputData, err = param.Decode(trigger.ExecutorParams, format)
+		if err != nil {
+			ctx := newJobLogCtx(NewCtxJobParamByTpp(trigger))
+			logger.LogJobf(ctx, "job#%d parse %s params error: %s", trigger.JobId, format, err.Error())
+			return nil, err
 		}
+
+		inputParam = param.Flatten(inputData)
+	} else {
+		// key=value lines
+		inputValues, err = param.ParseKV(trigger.ExecutorParams)
+		if err != nil {
+			ctx := newJobLogCtx(NewCtxJobParamByTpp(trigger))
+			logger.LogJobf(ctx, "job#%d parse params error: %s", trigger.JobId, err.Error())
+			return nil, err
+		}
+
+		inputParam = inputValues.ToMap()
 	}
 
-	funcName := goutil.FuncName(b.RunFunc)
-	lastSlash := strings.LastIndex(funcName, "/") + 1
-	funcName = funcName[lastSlash:]
+	funcName := b.Tag
+	if funcName == "" {
+		funcName = goutil.FuncName(b.RunFunc)
+		lastSlash := strings.LastIndex(funcName, "/") + 1
+		funcName = funcName[lastSlash:]
+	}
+
+	// ensure 'fullParam' key always exists.
+	inputParam["fullParam"] = trigger.ExecutorParams
 
 	jrp = NewJobRunParam(trigger).WithOptionFn(func(jrp *JobRunParam) {
 		jrp.JobTag = funcName
 		jrp.InputParam = inputParam
+		jrp.InputData = inputData
+		jrp.InputValues = inputValues
+		jrp.ParamFormat = format
 	})
 
 	return jrp, nil
@@ -75,51 +104,102 @@ func (b *BeanHandler) ParseJob(trigger *transport.TriggerParam) (jrp *JobRunPara
 // Execute bean handler func
 func (b *BeanHandler) Execute(jobId int32, glueType string, runParam *JobRunParam) error {
 	logId := runParam.LogId
-
 	cjp := NewCtxJobParamByJrp(jobId, runParam)
-	baseCtx := context.Background()
-
-	// add recover handle
-	defer func() {
-		if err := recover(); err != nil {
-			var (
-				errMsg string
-				ok     bool
-				e      error
-			)
-
-			if errMsg, ok = err.(string); !ok {
-				if e, ok = err.(error); ok {
-					errMsg = e.Error()
-				}
-			}
 
-			if errMsg == "" {
-				errMsg = "system error"
-			}
+	traceCtx, span := tracing.StartSpan(runParam.traceContext(), tracing.SpanBeanExecute, taskSpanAttrs(jobId, runParam)...)
 
-			ctx := context.WithValue(baseCtx, constants.CtxParamKey, cjp)
-			logger.LogJobf(ctx, "bean job task#%d run fatal! error: %s", logId, errMsg)
-		}
-	}()
-
-	valueCtx, canFun := context.WithCancel(baseCtx)
-	runParam.CurrentCancelFunc = canFun
+	valueCtx, canFun := runParam.newRunContext(traceCtx)
+	runParam.setCancel(canFun)
 	defer canFun()
 
 	// with job params
 	ctx := context.WithValue(valueCtx, constants.CtxParamKey, cjp)
 	logger.LogJobf(ctx, "bean job task#%d start run!", logId)
 
-	// do run
-	err := b.RunFunc(ctx)
+	// do run. run on new goroutine, so can return on timeout.
+	// build the func before, the timeout goroutine may still running on the handler changed.
+	runFn := chainMiddlewares(b.RunFunc, b.Middlewares)
+	done := make(chan error, 1)
+	go func() {
+		done <- runWithRecover(ctx, runFn)
+	}()
+
+	var (
+		err      error
+		timedOut bool
+	)
+	select {
+	case err = <-done:
+	case <-valueCtx.Done():
+		select {
+		case err = <-done: // the handler returned at the same time
+		default:
+			if valueCtx.Err() == context.DeadlineExceeded {
+				timedOut = true
+				waitTimeoutReturn(ctx, done, jobId, logId)
+			} else {
+				// canceled by kill, wait handler return.
+				err = <-done
+			}
+		}
+	}
+
+	if timedOut {
+		logger.Error("bean job task execute timeout", "jobId", jobId, "logId", logId, "timeout", runParam.Timeout)
+		logger.LogJobf(ctx, "bean job task#%d run failed! job timeout, timeout: %s", logId, runParam.Timeout)
+
+		err = runParam.timeoutError()
+		endSpan(span, err)
+		return err
+	}
+
+	// the handler reported a failed result
+	if err == nil {
+		err = runParam.resultError()
+	}
+
+	endSpan(span, err)
 	if err != nil {
-		logger.Errorf("bean job#%d - task#%d execute failed. error: %s", jobId, logId, err.Error())
+		logger.Error("bean job task execute failed", "jobId", jobId, "logId", logId, "error", err)
 		logger.LogJobf(ctx, "bean job task#%d run failed! error: %s", logId, err.Error())
 		return err
 	}
 
-	logger.Debugf("bean job#%d - run task#%d handle success", jobId, logId)
+	logger.Debug("bean job task handle success", "jobId", jobId, "logId", logId)
 	logger.LogJobf(ctx, "bean job task#%d run success!", logId)
 	return nil
 }
+
+// waitTimeoutReturn wait the handler return after the task timeout, avoid it run with the next task.
+// the handler not return in BeanTimeoutGrace will be leaked.
+func waitTimeoutReturn(ctx context.Context, done <-chan error, jobId int32, logId int64) {
+	timer := time.NewTimer(BeanTimeoutGrace)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
+		logger.Error("bean job handler not return after timeout, the goroutine is leaked", "jobId", jobId, "logId", logId, "grace", BeanTimeoutGrace)
+		logger.LogJobf(ctx, "bean job task#%d handler not return in %s after timeout, it may be still running", logId, BeanTimeoutGrace)
+	}
+}
+
+// runWithRecover call the run func, with recover handle. it is shared with RecoverMiddleware.
+// the panic will be returned as *PanicError, and the stack trace is written to job log.
+func runWithRecover(ctx context.Context, runFn BeanJobRunFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			var logId int64
+			if cjp, _ := GetCtxJobParam(ctx); cjp != nil {
+				logId = cjp.LogID
+			}
+
+			pe := NewPanicError(r)
+			logger.LogJobf(ctx, "bean job task#%d run fatal! %s\n%s", logId, pe.Error(), pe.Stack)
+
+			err = pe
+		}
+	}()
+
+	return runFn(ctx)
+}
@@ -0,0 +1,215 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/constants"
+	"github.com/goft-cloud/go-xxl-job-client/v2/handler"
+	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+	"github.com/goft-cloud/go-xxl-job-client/v2/param"
+	"github.com/goft-cloud/go-xxl-job-client/v2/tracing"
+	"github.com/goft-cloud/go-xxl-job-client/v2/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "xxl-job-test")
+	if err != nil {
+		panic(err)
+	}
+
+	logger.SetLogBasePath(dir)
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestBeanHandler_Execute_timeout(t *testing.T) {
+	bh := &handler.BeanHandler{RunFunc: func(ctx context.Context) error {
+		time.Sleep(3 * time.Second)
+		return nil
+	}}
+
+	jrp, err := bh.ParseJob(&transport.TriggerParam{JobId: 1, LogId: 11, ExecutorTimeout: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, jrp.Timeout)
+
+	start := time.Now()
+	err = bh.Execute(1, "BEAN", jrp)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, handler.ErrJobTimeout))
+	assert.Less(t, int64(time.Since(start)), int64(2*time.Second))
+
+	// not timeout
+	bh.RunFunc = func(ctx context.Context) error {
+		return nil
+	}
+	assert.NoError(t, bh.Execute(1, "BEAN", jrp))
+}
+
+func TestBeanHandler_Execute_timeoutWait(t *testing.T) {
+	var returned int32
+	bh := &handler.BeanHandler{RunFunc: func(ctx context.Context) error {
+		<-ctx.Done()
+		// cleanup after canceled
+		time.Sleep(100 * time.Millisecond)
+		atomic.StoreInt32(&returned, 1)
+		return ctx.Err()
+	}}
+
+	jrp, err := bh.ParseJob(&transport.TriggerParam{JobId: 1, LogId: 12, ExecutorTimeout: 1})
+	assert.NoError(t, err)
+
+	err = bh.Execute(1, "BEAN", jrp)
+	assert.True(t, errors.Is(err, handler.ErrJobTimeout))
+	// wait the handler returned
+	assert.Equal(t, int32(1), atomic.LoadInt32(&returned))
+}
+
+type testSpan struct {
+	name   string
+	parent string
+	attrs  map[string]interface{}
+	err    error
+	ended  bool
+}
+
+func (s *testSpan) SetAttributes(attrs ...tracing.Attr) {
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+func (s *testSpan) RecordError(err error) { s.err = err }
+func (s *testSpan) End()                  { s.ended = true }
+
+func TestBeanHandler_Execute_tracing(t *testing.T) {
+	var spans []*testSpan
+	tracing.SetTracer(tracing.TracerFunc(func(ctx context.Context, name string, attrs ...tracing.Attr) (context.Context, tracing.Span) {
+		span := &testSpan{name: name, attrs: map[string]interface{}{}}
+		if parent, ok := tracing.SpanFromContext(ctx).(*testSpan); ok {
+			span.parent = parent.name
+		}
+
+		span.SetAttributes(attrs...)
+		spans = append(spans, span)
+		return ctx, span
+	}))
+	defer tracing.SetTracer(nil)
+
+	bh := &handler.BeanHandler{RunFunc: func(ctx context.Context) error {
+		_, span := tracing.StartSpan(ctx, "child")
+		span.End()
+		return errors.New("handle failed")
+	}}
+
+	jrp, err := bh.ParseJob(&transport.TriggerParam{JobId: 2, LogId: 21, ExecutorHandler: "demo", BroadcastTotal: 2})
+	assert.NoError(t, err)
+	assert.Error(t, bh.Execute(2, "BEAN", jrp))
+
+	assert.Len(t, spans, 2)
+	assert.Equal(t, tracing.SpanBeanExecute, spans[0].name)
+	assert.Equal(t, int32(2), spans[0].attrs[tracing.AttrJobId])
+	assert.Equal(t, int64(21), spans[0].attrs[tracing.AttrLogId])
+	assert.Equal(t, "demo", spans[0].attrs[tracing.AttrHandler])
+	assert.Equal(t, int32(500), spans[0].attrs[tracing.AttrResult])
+	assert.EqualError(t, spans[0].err, "handle failed")
+	assert.True(t, spans[0].ended)
+
+	assert.Equal(t, "child", spans[1].name)
+	assert.Equal(t, tracing.SpanBeanExecute, spans[1].parent)
+}
+
+func TestBeanHandler_Execute_panic(t *testing.T) {
+	bh := &handler.BeanHandler{RunFunc: func(ctx context.Context) error {
+		var m map[string]int
+		m["a"] = 1
+		return nil
+	}}
+
+	jrp, err := bh.ParseJob(&transport.TriggerParam{JobId: 4, LogId: 41})
+	assert.NoError(t, err)
+
+	err = bh.Execute(4, "BEAN", jrp)
+	var pe *handler.PanicError
+	assert.True(t, errors.As(err, &pe))
+	assert.True(t, strings.HasPrefix(err.Error(), "job panic: assignment to entry in nil map"))
+	assert.Contains(t, string(pe.Stack), "TestBeanHandler_Execute_panic")
+
+	// the stack is written to task log
+	bs, err := ioutil.ReadFile(logger.LogfilePath(41))
+	assert.NoError(t, err)
+	assert.Contains(t, string(bs), "run fatal! job panic")
+	assert.Contains(t, string(bs), "TestBeanHandler_Execute_panic")
+}
+
+func TestBeanHandler_ParseJob_format(t *testing.T) {
+	bh := &handler.BeanHandler{RunFunc: func(ctx context.Context) error {
+		return nil
+	}}
+
+	params := `{"name": "inhere", "db": {"host": "localhost"}}`
+	jrp, err := bh.ParseJob(&transport.TriggerParam{JobId: 5, LogId: 51, ExecutorParams: params})
+	assert.NoError(t, err)
+	assert.Equal(t, param.FormatJSON, jrp.ParamFormat)
+	assert.Equal(t, "localhost", jrp.InputParam["db.host"])
+	assert.Equal(t, params, jrp.InputParam["fullParam"])
+
+	cjp := handler.NewCtxJobParamByJrp(5, jrp)
+	assert.Equal(t, "inhere", cjp.Data["name"])
+
+	// format hint
+	bh.ParamFormat = param.FormatYAML
+	_, err = bh.ParseJob(&transport.TriggerParam{JobId: 5, LogId: 52, ExecutorParams: "name: [x"})
+	assert.Error(t, err)
+}
+
+func TestBeanHandler_Execute_sharding(t *testing.T) {
+	var idx, total int32
+	bh := &handler.BeanHandler{RunFunc: func(ctx context.Context) error {
+		cjp, err := handler.GetCtxJobParam(ctx)
+		if err == nil {
+			idx, total = cjp.ShardIndex, cjp.ShardTotal
+		}
+		return err
+	}}
+
+	jrp, err := bh.ParseJob(&transport.TriggerParam{JobId: 13, LogId: 131, BroadcastIndex: 1, BroadcastTotal: 3})
+	assert.NoError(t, err)
+	assert.NoError(t, bh.Execute(13, "BEAN", jrp))
+	assert.Equal(t, int32(1), idx)
+	assert.Equal(t, int32(3), total)
+}
+
+func TestBeanHandler_Execute_handleResult(t *testing.T) {
+	code := int32(constants.HandleCodeSuccess)
+	bh := &handler.BeanHandler{RunFunc: func(ctx context.Context) error {
+		cjp, err := handler.GetCtxJobParam(ctx)
+		if err == nil {
+			cjp.Result.Set(code, "processed 10 rows")
+		}
+		return err
+	}}
+
+	jrp, err := bh.ParseJob(&transport.TriggerParam{JobId: 14, LogId: 141})
+	assert.NoError(t, err)
+	assert.NoError(t, bh.Execute(14, "BEAN", jrp))
+
+	// report failed result
+	code = constants.HandleCodeFail
+	jrp, err = bh.ParseJob(&transport.TriggerParam{JobId: 14, LogId: 142})
+	assert.NoError(t, err)
+
+	err = bh.Execute(14, "BEAN", jrp)
+	var he *handler.HandleError
+	if assert.True(t, errors.As(err, &he)) {
+		assert.Equal(t, int32(constants.HandleCodeFail), he.Code)
+		assert.Equal(t, "processed 10 rows", he.Error())
+	}
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"context"
+	"sync"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/constants"
+	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+	"github.com/goft-cloud/go-xxl-job-client/v2/param"
+)
+
+// BeanJobLifecycle the optional lifecycle hooks of the BeanJobRunner. like the IJobHandler.init(), destroy() in java.
+//
+// the hooks are called per jobId: the admin can bind several jobs to a runner, each jobId has its own Init and Destroy calls.
+// the ctx has the job param with JobID and JobName, the runner shared by jobs should manage the resources per JobID,
+// the Destroy of a jobId must not release the resources still used by other jobIds.
+type BeanJobLifecycle interface {
+	// Init call before run the first task of the job. on error, the task will be failed and Init will be retried on next task.
+	Init(ctx context.Context) error
+	// Destroy call on the job runner is stopped: the task killed, the job evicted or the executor shutdown.
+	Destroy(ctx context.Context) error
+}
+
+// jobLifecycle manage the Init and Destroy calls of a job
+type jobLifecycle struct {
+	mu      sync.Mutex
+	hooks   BeanJobLifecycle
+	jobId   int32
+	jobName string
+	inited  bool
+}
+
+// newJobLifecycle create, return nil on the runner has no lifecycle hooks.
+func newJobLifecycle(runner BeanJobRunner, jobId int32, jobName string) *jobLifecycle {
+	hooks, ok := runner.(BeanJobLifecycle)
+	if !ok {
+		return nil
+	}
+	return &jobLifecycle{hooks: hooks, jobId: jobId, jobName: jobName}
+}
+
+func (l *jobLifecycle) context() context.Context {
+	cjp := &param.CtxJobParam{JobID: l.jobId, JobName: l.jobName, JobFunc: l.jobName}
+	return context.WithValue(context.Background(), constants.CtxParamKey, cjp)
+}
+
+// init call the Init hook, if not inited.
+func (l *jobLifecycle) init() (err error) {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inited {
+		return nil
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = NewPanicError(r)
+		}
+	}()
+
+	if err = l.hooks.Init(l.context()); err != nil {
+		logger.Error("bean job init failed", "jobId", l.jobId, "jobName", l.jobName, "error", err.Error())
+		return err
+	}
+
+	l.inited = true
+	logger.Debug("bean job inited", "jobId", l.jobId, "jobName", l.jobName)
+	return nil
+}
+
+// destroy call the Destroy hook, if has been inited.
+func (l *jobLifecycle) destroy(reason string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.inited {
+		return
+	}
+	l.inited = false
+
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("bean job destroy panic", "jobId", l.jobId, "jobName", l.jobName, "error", NewPanicError(r).Error())
+		}
+	}()
+
+	if err := l.hooks.Destroy(l.context()); err != nil {
+		logger.Error("bean job destroy failed", "jobId", l.jobId, "jobName", l.jobName, "reason", reason, "error", err.Error())
+		return
+	}
+	logger.Debug("bean job destroyed", "jobId", l.jobId, "jobName", l.jobName, "reason", reason)
+}
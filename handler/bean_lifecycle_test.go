@@ -0,0 +1,96 @@
+package handler_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/constants"
+	"github.com/goft-cloud/go-xxl-job-client/v2/handler"
+	"github.com/goft-cloud/go-xxl-job-client/v2/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+type lifecycleJob struct {
+	inits, destroys int32
+}
+
+func (j *lifecycleJob) Init(ctx context.Context) error {
+	atomic.AddInt32(&j.inits, 1)
+	return nil
+}
+
+func (j *lifecycleJob) Destroy(ctx context.Context) error {
+	atomic.AddInt32(&j.destroys, 1)
+	return nil
+}
+
+func (j *lifecycleJob) Handle(ctx context.Context) error {
+	cjp, _ := handler.GetCtxJobParam(ctx)
+	if cjp.Param("wait") != "" {
+		<-ctx.Done()
+	}
+	return nil
+}
+
+func TestJobManager_lifecycle(t *testing.T) {
+	done := make(chan error, 10)
+	jm := &handler.JobManager{
+		QueueMap: make(map[int32]*handler.JobQueue),
+		CallbackFunc: func(trigger *handler.JobRunParam, runErr error) {
+			done <- runErr
+		},
+	}
+
+	job := &lifecycleJob{}
+	jm.RegisterRunner("lifecycle", job)
+
+	trigger := &transport.TriggerParam{JobId: 15, LogId: 151, ExecutorHandler: "lifecycle"}
+	assert.NoError(t, jm.PutJobToQueue(trigger))
+	assert.NoError(t, <-done)
+
+	trigger.LogId = 152
+	assert.NoError(t, jm.PutJobToQueue(trigger))
+	assert.NoError(t, <-done)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&job.inits))
+
+	// killed by cover early, will destroy and init again
+	trigger.LogId = 153
+	trigger.ExecutorParams = "wait=1"
+	assert.NoError(t, jm.PutJobToQueue(trigger))
+	time.Sleep(50 * time.Millisecond)
+
+	trigger.LogId = 154
+	trigger.ExecutorParams = ""
+	trigger.ExecutorBlockStrategy = constants.BlockCoverEarly
+	assert.NoError(t, jm.PutJobToQueue(trigger))
+	assert.Error(t, <-done)
+	assert.NoError(t, <-done)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&job.inits))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&job.destroys))
+
+	// destroy on shutdown
+	assert.NoError(t, jm.Shutdown(context.Background()))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&job.destroys))
+}
+
+func tagJob(ctx context.Context) error { return nil }
+
+func TestJobManager_jobTag(t *testing.T) {
+	tags := make(chan string, 10)
+	jm := &handler.JobManager{
+		CallbackFunc: func(trigger *handler.JobRunParam, runErr error) {
+			tags <- trigger.JobTag
+		},
+	}
+
+	jm.RegisterJob("tag_func", tagJob)
+	jm.RegisterRunner("tag_runner", &lifecycleJob{})
+
+	// the func job use the func name, the runner job use the runner type.
+	assert.NoError(t, jm.PutJobToQueue(&transport.TriggerParam{JobId: 24, LogId: 241, ExecutorHandler: "tag_func"}))
+	assert.Equal(t, "handler_test.tagJob", <-tags)
+	assert.NoError(t, jm.PutJobToQueue(&transport.TriggerParam{JobId: 24, LogId: 242, ExecutorHandler: "tag_runner"}))
+	assert.Equal(t, "*handler_test.lifecycleJob", <-tags)
+}
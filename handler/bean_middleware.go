@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+)
+
+// BeanJobMiddleware wrap the bean job run func, can do something before and after run it.
+//
+// Usage:
+//
+//	func(next handler.BeanJobRunFunc) handler.BeanJobRunFunc {
+//		return func(ctx context.Context) error {
+//			// do something ...
+//			return next(ctx)
+//		}
+//	}
+type BeanJobMiddleware func(next BeanJobRunFunc) BeanJobRunFunc
+
+// chainMiddlewares wrap the run func by middlewares. the first middleware is the outermost.
+func chainMiddlewares(fn BeanJobRunFunc, mws []BeanJobMiddleware) BeanJobRunFunc {
+	for i := len(mws) - 1; i >= 0; i-- {
+		fn = mws[i](fn)
+	}
+	return fn
+}
+
+// RecoverMiddleware recover the panic of bean job, write the stack trace to job log and return it as error.
+func RecoverMiddleware() BeanJobMiddleware {
+	return func(next BeanJobRunFunc) BeanJobRunFunc {
+		return func(ctx context.Context) error {
+			return runWithRecover(ctx, next)
+		}
+	}
+}
+
+// DurationMiddleware write the bean job run duration to job log.
+func DurationMiddleware() BeanJobMiddleware {
+	return func(next BeanJobRunFunc) BeanJobRunFunc {
+		return func(ctx context.Context) error {
+			start := time.Now()
+			err := next(ctx)
+
+			logger.LogJobf(ctx, "bean job run completed, duration: %s", time.Since(start))
+			return err
+		}
+	}
+}
+
+// SlowJobMiddleware warn on the bean job run duration is exceeded the threshold.
+func SlowJobMiddleware(threshold time.Duration) BeanJobMiddleware {
+	return func(next BeanJobRunFunc) BeanJobRunFunc {
+		return func(ctx context.Context) error {
+			start := time.Now()
+			err := next(ctx)
+
+			if cost := time.Since(start); cost > threshold {
+				cjp, _ := GetCtxJobParam(ctx)
+				if cjp != nil {
+					logger.Warn("bean job run slowly", "jobId", cjp.JobID, "logId", cjp.LogID, "duration", cost, "threshold", threshold)
+				}
+				logger.LogJobf(ctx, "WARNING: bean job run slowly, duration: %s, threshold: %s", cost, threshold)
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package handler_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/handler"
+	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+	"github.com/goft-cloud/go-xxl-job-client/v2/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBeanHandler_Middlewares(t *testing.T) {
+	var calls []string
+	mw := func(name string) handler.BeanJobMiddleware {
+		return func(next handler.BeanJobRunFunc) handler.BeanJobRunFunc {
+			return func(ctx context.Context) error {
+				calls = append(calls, name+":before")
+				err := next(ctx)
+				calls = append(calls, name+":after")
+				return err
+			}
+		}
+	}
+
+	bh := &handler.BeanHandler{
+		RunFunc: func(ctx context.Context) error {
+			calls = append(calls, "job")
+			return nil
+		},
+		Middlewares: []handler.BeanJobMiddleware{mw("global"), mw("job")},
+	}
+
+	jrp, err := bh.ParseJob(&transport.TriggerParam{JobId: 3, LogId: 31})
+	assert.NoError(t, err)
+	assert.NoError(t, bh.Execute(3, "BEAN", jrp))
+	assert.Equal(t, []string{"global:before", "job:before", "job", "job:after", "global:after"}, calls)
+}
+
+func TestRecoverMiddleware(t *testing.T) {
+	bh := &handler.BeanHandler{
+		RunFunc: func(ctx context.Context) error {
+			panic("oops")
+		},
+		Middlewares: []handler.BeanJobMiddleware{handler.RecoverMiddleware(), handler.DurationMiddleware()},
+	}
+
+	_ = os.Remove(logger.LogfilePath(32))
+	jrp, err := bh.ParseJob(&transport.TriggerParam{JobId: 3, LogId: 32})
+	assert.NoError(t, err)
+	assert.EqualError(t, bh.Execute(3, "BEAN", jrp), "job panic: oops")
+
+	// the stack trace is written to job log once
+	assert.Equal(t, 1, strings.Count(jobLog(t, 32), "bean job task#32 run fatal! job panic: oops"))
+}
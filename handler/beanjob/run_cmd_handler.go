@@ -61,49 +61,33 @@ func (ch RunCmdHandler) Handle(ctx context.Context) error {
 	logger.LogJobf(ctx, "task#%d start run the cmdline: %s %s", logId, cmdName, argsLine)
 
 	// build command
-	cmd := exec.CommandContext(ctx, cmdName, args...)
+	cmd := exec.Command(cmdName, args...)
 
-	// add shard ENV
-	// if obj.ShardTotal > 0 {
-	// 	cmd.Env = []string{
-	// 		constants.EnvXxlShardIdx + "=" + strutil.MustString(obj.ShardIndex),
-	// 		constants.EnvXxlShardTotal + "=" + strutil.MustString(obj.ShardTotal),
-	// 	}
-	// }
+	// set env, work dir and run as user. see handler.ScriptOptions
+	limits, err := handler.PrepareCmd(cmd, obj, handler.GlueTypeBean)
+	if err != nil {
+		return err
+	}
 
 	logfile := logger.LogfilePath(logId)
-	fh, err := logger.OpenLogFile(logfile)
+	// read the stdout and stderr by pipes, write to log file with prefix
+	out, err := handler.OpenCmdOutput(cmd, logfile)
 	if err != nil {
 		return err
 	}
 
-	// use file receive output
-	cmd.Stderr = fh
-	cmd.Stdout = fh
-
-	// see: https://stackoverflow.com/questions/48926982/write-stdout-stream-to-file
-	// stdout, err := cmd.StdoutPipe()
-	// if err != nil {
-	// 	return err
-	// }
-	// go io.Copy(fh, stdout)
-
-	logger.Debugf("cmd job#%d will run task#%d cmdline: '%s', logfile: %s", jobId, logId, cmd.String(), logfile)
-	if err := cmd.Run(); err != nil {
-		_ = fh.Close() // close log file.
-
-		// dump.P(err)
-		errMsg := err.Error()
-		if ee, ok := err.(*exec.ExitError); ok {
-			errMsg = ee.String() + "; " + string(ee.Stderr)
-			logger.Errorf("cmd job#%d - run task#%d cmdline error: %s", jobId, logId, errMsg)
-		}
+	logger.Debug("cmd job will run task", "jobId", jobId, "logId", logId, "cmdline", cmd.String(), "logfile", logfile)
+	// will stop the process group on job killed or timeout
+	if err := handler.RunCmd(ctx, cmd, limits); err != nil {
+		_ = out.Close() // close log file.
 
+		// the stderr output is written to the task log
+		logger.Error("cmd job run task failed", "jobId", jobId, "logId", logId, "error", err)
 		return err
 	}
 
 	// close log file.
-	return fh.Close()
+	return out.Close()
 }
 
 // BuildHelp task
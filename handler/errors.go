@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// ErrJobTimeout the task run exceeded the ExecutorTimeout
+var ErrJobTimeout = errors.New("job execute timeout")
+
+// ErrShuttingDown the executor is shutting down, will not accept new triggers
+var ErrShuttingDown = errors.New("executor is shutting down")
+
+// PanicError the bean job run panic, with the captured stack trace.
+type PanicError struct {
+	// Value the recovered panic value
+	Value interface{}
+	// Stack trace of the panic goroutine
+	Stack []byte
+}
+
+// NewPanicError create, will capture the current stack trace.
+// should be called in the deferred recover func.
+func NewPanicError(val interface{}) *PanicError {
+	return &PanicError{Value: val, Stack: debug.Stack()}
+}
+
+// Error string
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("job panic: %v", e.Value)
+}
+
+// Unwrap the panic value if it is an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// HandleError the task is failed by the result reported by handler. see xxl.HandleFail()
+type HandleError struct {
+	// Code the result code. eg: constants.HandleCodeFail
+	Code int32
+	Msg  string
+}
+
+// Error string
+func (e *HandleError) Error() string {
+	if e.Msg == "" {
+		return fmt.Sprintf("job handle failed, code: %d", e.Code)
+	}
+	return e.Msg
+}
@@ -0,0 +1,18 @@
+package handler
+
+import "github.com/goft-cloud/go-xxl-job-client/v2/transport"
+
+// CancelJob export the admin kill for tests
+func (jm *JobManager) CancelJob(jobId int32) {
+	jm.cancelJob(jobId)
+}
+
+// PushJob export the run request handle for tests
+func (rp *RequestProcess) PushJob(trigger *transport.TriggerParam) {
+	rp.pushJob(trigger)
+}
+
+// Finish export the task resources release for tests
+func (jrp *JobRunParam) Finish() {
+	jrp.finish()
+}
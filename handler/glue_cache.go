@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+)
+
+var (
+	// lock for write and remove the glue script files
+	glueFileMu sync.Mutex
+	// glueRefs the number of the queued and running tasks reference the glue script. key is the path
+	glueRefs = make(map[string]int)
+	// glueLatest the latest written glue script path of the job
+	glueLatest = make(map[int32]string)
+)
+
+// glueScriptPath build the glue script file path. eg: job12_1641052800000.sh
+func glueScriptPath(jobId int32, updateTime int64, suffix string) string {
+	return filepath.Join(logger.GlueSourcePath(), fmt.Sprintf("job%d_%d%s", jobId, updateTime, suffix))
+}
+
+// writeGlueScript ensure the glue script file exists and the content is same as the source.
+// the script is referenced until call the release func, it will not be removed before that.
+//
+// the file is written to a temp file and then rename, so will not use a partially written file.
+// after written, the superseded versions of the job without reference will be removed.
+func writeGlueScript(jobId int32, updateTime int64, suffix, source string) (path string, release func(), err error) {
+	path = glueScriptPath(jobId, updateTime, suffix)
+
+	glueFileMu.Lock()
+	defer glueFileMu.Unlock()
+
+	if err = ensureGlueScript(jobId, path, source); err != nil {
+		return "", nil, err
+	}
+
+	glueRefs[path]++
+	glueLatest[jobId] = path
+	removeSupersededGlues(jobId, filepath.Base(path))
+
+	var once sync.Once
+	return path, func() {
+		once.Do(func() {
+			releaseGlueScript(jobId, path)
+		})
+	}, nil
+}
+
+func ensureGlueScript(jobId int32, path, source string) error {
+	bs, err := ioutil.ReadFile(path)
+	if err == nil {
+		if sha256.Sum256(bs) == sha256.Sum256([]byte(source)) {
+			return nil
+		}
+		logger.Warn("the glue script content is mismatched, will rewrite it", "jobId", jobId, "file", path)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := os.MkdirAll(logger.GlueSourcePath(), os.ModePerm); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(logger.GlueSourcePath(), fmt.Sprintf(".job%d_*.tmp", jobId))
+	if err != nil {
+		return err
+	}
+
+	tmpPath := tmp.Name()
+	if err := writeAndClose(tmp, source); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("write glue script file failed: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// releaseGlueScript release a reference of the glue script, remove it on it is superseded and no reference.
+func releaseGlueScript(jobId int32, path string) {
+	glueFileMu.Lock()
+	defer glueFileMu.Unlock()
+
+	if glueRefs[path]--; glueRefs[path] > 0 {
+		return
+	}
+
+	delete(glueRefs, path)
+	if latest, ok := glueLatest[jobId]; !ok || latest == path {
+		return
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		logger.Error("remove superseded glue script failed", "file", path, "jobId", jobId, "error", err.Error())
+		return
+	}
+	logger.Debug("removed superseded glue script", "file", path, "jobId", jobId)
+}
+
+// shareGlueScript change the group of the glue script to the run as group, the script mode is 0750.
+func shareGlueScript(path string, cred *Credential) error {
+	return os.Chown(path, -1, int(cred.Gid))
+}
+
+func writeAndClose(fh *os.File, source string) error {
+	_, err := fh.WriteString(source)
+	if err == nil {
+		err = fh.Chmod(0750)
+	}
+	if err == nil {
+		err = fh.Sync()
+	}
+
+	if cErr := fh.Close(); err == nil {
+		err = cErr
+	}
+	return err
+}
+
+// removeSupersededGlues remove the other versions of the job glue script, the referenced versions are kept.
+// must be called with the glueFileMu.
+func removeSupersededGlues(jobId int32, keep string) {
+	files, err := logger.ListGlueFiles()
+	if err != nil {
+		logger.Error("list glue script files failed", "error", err.Error())
+		return
+	}
+
+	for _, gf := range files {
+		if gf.JobId != jobId || gf.Name == keep || glueRefs[gf.Path] > 0 {
+			continue
+		}
+
+		if err := os.Remove(gf.Path); err != nil {
+			logger.Error("remove superseded glue script failed", "file", gf.Name, "jobId", jobId, "error", err.Error())
+			continue
+		}
+		logger.Debug("removed superseded glue script", "file", gf.Name, "jobId", jobId)
+	}
+}
+
+// ListGlueScripts list the cached glue script files
+func ListGlueScripts() ([]logger.GlueFile, error) {
+	return logger.ListGlueFiles()
+}
+
+// PurgeGlueScripts remove the cached glue scripts and the temp files of the jobs.
+// will remove all jobs on the jobIds is empty.
+//
+// the scripts of the jobs that have running task, and the scripts referenced by tasks will be skipped.
+func (jm *JobManager) PurgeGlueScripts(jobIds ...int32) (removed int, err error) {
+	match := make(map[int32]bool, len(jobIds))
+	for _, jobId := range jobIds {
+		match[jobId] = true
+	}
+
+	glueFileMu.Lock()
+	defer glueFileMu.Unlock()
+
+	files, err := logger.ListGlueFiles()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, gf := range files {
+		if len(match) > 0 && !match[gf.JobId] || jm.glueInUse(gf) {
+			continue
+		}
+
+		if err = os.Remove(gf.Path); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+
+	// the temp files are left on crash when writing
+	tmpFiles, err := logger.ListGlueTempFiles()
+	if err != nil {
+		return removed, err
+	}
+
+	for _, path := range tmpFiles {
+		var jobId int32
+		if _, err := fmt.Sscanf(filepath.Base(path), ".job%d_", &jobId); err != nil {
+			continue
+		}
+		if len(match) > 0 && !match[jobId] {
+			continue
+		}
+
+		if err = os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// RemoveStaleGlue remove the stale glue script by the log cleaner, return false on it is in use.
+// it is synced with the glue script writing.
+func (jm *JobManager) RemoveStaleGlue(gf logger.GlueFile) (bool, error) {
+	glueFileMu.Lock()
+	defer glueFileMu.Unlock()
+
+	if jm.glueInUse(gf) {
+		return false, nil
+	}
+
+	if err := os.Remove(gf.Path); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// glueInUse check the glue script is referenced by task, or the job has running task.
+// must be called with the glueFileMu.
+func (jm *JobManager) glueInUse(gf logger.GlueFile) bool {
+	return glueRefs[gf.Path] > 0 || jm.HasRunning(gf.JobId)
+}
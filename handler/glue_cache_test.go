@@ -0,0 +1,122 @@
+package handler_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/constants"
+	"github.com/goft-cloud/go-xxl-job-client/v2/handler"
+	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+	"github.com/goft-cloud/go-xxl-job-client/v2/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScriptHandler_ParseJob_glueCache(t *testing.T) {
+	sh := &handler.ScriptHandler{}
+	trigger := &transport.TriggerParam{
+		JobId:          11,
+		LogId:          111,
+		GlueType:       "GLUE_SHELL",
+		GlueSource:     "echo v1\n",
+		GlueUpdatetime: 1,
+	}
+
+	jrp1, err := sh.ParseJob(trigger)
+	assert.NoError(t, err)
+	v1 := jrp1.JobTag
+
+	// the partially written file will be rewritten
+	assert.NoError(t, ioutil.WriteFile(v1, []byte("ech"), 0750))
+	jrp2, err := sh.ParseJob(trigger)
+	assert.NoError(t, err)
+	bs, err := ioutil.ReadFile(v1)
+	assert.NoError(t, err)
+	assert.Equal(t, "echo v1\n", string(bs))
+
+	// the superseded version is kept before the tasks reference it completed
+	trigger.GlueSource = "echo v2\n"
+	trigger.GlueUpdatetime = 2
+	jrp, err := sh.ParseJob(trigger)
+	assert.NoError(t, err)
+	assert.FileExists(t, v1)
+	assert.FileExists(t, jrp.JobTag)
+
+	jm := &handler.JobManager{}
+	ok, err := jm.RemoveStaleGlue(logger.GlueFile{JobId: 11, Path: v1})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	jrp1.Finish()
+	assert.FileExists(t, v1)
+	jrp2.Finish()
+	assert.NoFileExists(t, v1)
+	jrp.Finish()
+
+	gfs, err := handler.ListGlueScripts()
+	assert.NoError(t, err)
+	var names []string
+	for _, gf := range gfs {
+		if gf.JobId == 11 {
+			names = append(names, gf.Name)
+		}
+	}
+	assert.Equal(t, []string{"job11_2.sh"}, names)
+
+	// purge with the left temp file
+	tmpFile := logger.GlueSourcePath() + "/.job11_123.tmp"
+	assert.NoError(t, ioutil.WriteFile(tmpFile, []byte("echo"), 0644))
+
+	removed, err := jm.PurgeGlueScripts(11)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, removed)
+	assert.NoFileExists(t, jrp.JobTag)
+	_, err = os.Stat(tmpFile)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestJobManager_glueSuperseded(t *testing.T) {
+	done := make(chan error, 10)
+	jm := &handler.JobManager{
+		CallbackFunc: func(trigger *handler.JobRunParam, runErr error) {
+			done <- runErr
+		},
+	}
+
+	flag := filepath.Join(logger.GlueSourcePath(), "job12.flag")
+	defer os.Remove(flag)
+	trigger := func(logId, updateTime int64) *transport.TriggerParam {
+		return &transport.TriggerParam{
+			JobId:                 12,
+			LogId:                 logId,
+			GlueType:              "GLUE_SHELL",
+			GlueSource:            fmt.Sprintf("while [ ! -f %s ]; do sleep 0.01; done\necho v%d\n", flag, updateTime),
+			GlueUpdatetime:        updateTime,
+			ExecutorBlockStrategy: constants.BlockSerialExecution,
+		}
+	}
+
+	assert.NoError(t, jm.PutJobToQueue(trigger(1201, 1)))
+	assert.Eventually(t, func() bool {
+		return jm.HasRunning(12)
+	}, time.Second, 10*time.Millisecond)
+
+	// the queued task of v1 can run after the v2 is written
+	assert.NoError(t, jm.PutJobToQueue(trigger(1202, 1)))
+	assert.NoError(t, jm.PutJobToQueue(trigger(1203, 2)))
+	v1 := filepath.Join(logger.GlueSourcePath(), "job12_1.sh")
+	assert.FileExists(t, v1)
+
+	assert.NoError(t, ioutil.WriteFile(flag, nil, 0644))
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, <-done)
+	}
+
+	// removed after the tasks completed
+	assert.NoFileExists(t, v1)
+	assert.FileExists(t, filepath.Join(logger.GlueSourcePath(), "job12_2.sh"))
+	assert.Contains(t, jobLog(t, 1202), "v1")
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"sync"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/constants"
+	"github.com/gookit/goutil/cliutil/cmdline"
+)
+
+// ErrUnsupportedGlueType the glue type is not registered
+var ErrUnsupportedGlueType = errors.New("unsupported glue type")
+
+// GlueType the script glue type config
+type GlueType struct {
+	// Name of the glue type. eg: "GLUE_PYTHON"
+	Name string
+	// Suffix of the script file. eg: ".py"
+	Suffix string
+	// Bin the interpreter name or path. eg: "python3"
+	Bin string
+	// Args the interpreter args, before the script file. eg: []string{"-u"}
+	Args []string
+	// Env custom env vars for the glue type scripts, will override the ScriptOptions.Env
+	Env map[string]string
+}
+
+// cmdArgs build the interpreter args with the script file and args
+func (gt GlueType) cmdArgs(args []string) []string {
+	ss := make([]string, 0, len(gt.Args)+len(args))
+	ss = append(ss, gt.Args...)
+	return append(ss, args...)
+}
+
+var (
+	glueMu    sync.RWMutex
+	glueTypes = map[string]GlueType{
+		"GLUE_SHELL":      {Name: "GLUE_SHELL", Suffix: ".sh", Bin: constants.ShellBash},
+		"GLUE_PYTHON":     {Name: "GLUE_PYTHON", Suffix: ".py", Bin: "python"},
+		"GLUE_PHP":        {Name: "GLUE_PHP", Suffix: ".php", Bin: "php"},
+		"GLUE_NODEJS":     {Name: "GLUE_NODEJS", Suffix: ".js", Bin: "node"},
+		"GLUE_POWERSHELL": {Name: "GLUE_POWERSHELL", Suffix: ".ps1", Bin: "powershell"},
+	}
+)
+
+// RegisterGlueType add or override the glue type.
+//
+// Usage:
+//
+//	handler.RegisterGlueType(handler.GlueType{Name: "GLUE_GROOVY", Suffix: ".groovy", Bin: "groovy"})
+func RegisterGlueType(gt GlueType) {
+	if gt.Name == "" || gt.Bin == "" {
+		panic("register glue type: the Name and Bin is required")
+	}
+
+	glueMu.Lock()
+	glueTypes[gt.Name] = gt
+	glueMu.Unlock()
+}
+
+// SetGlueBin set the interpreter of the glue type, can be with args. eg: "python3 -u"
+func SetGlueBin(name, binLine string) error {
+	args := cmdline.ParseLine(binLine)
+	if len(args) == 0 {
+		return fmt.Errorf("the interpreter of glue type %s is empty", name)
+	}
+
+	glueMu.Lock()
+	defer glueMu.Unlock()
+
+	gt, ok := glueTypes[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnsupportedGlueType, name)
+	}
+
+	gt.Bin, gt.Args = args[0], args[1:]
+	glueTypes[name] = gt
+	return nil
+}
+
+// GetGlueType get the glue type config by name
+func GetGlueType(name string) (GlueType, bool) {
+	glueMu.RLock()
+	defer glueMu.RUnlock()
+
+	gt, ok := glueTypes[name]
+	return gt, ok
+}
+
+// GlueTypeNames get the registered glue type names, sorted.
+func GlueTypeNames() []string {
+	glueMu.RLock()
+	defer glueMu.RUnlock()
+
+	names := make([]string, 0, len(glueTypes))
+	for name := range glueTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckGlueTypes check the interpreters of glue types exist on PATH. return the errors by glue type name.
+func CheckGlueTypes() map[string]error {
+	errs := make(map[string]error)
+	for _, name := range GlueTypeNames() {
+		gt, _ := GetGlueType(name)
+		if _, err := exec.LookPath(gt.Bin); err != nil {
+			errs[name] = fmt.Errorf("the interpreter %q of glue type %s not found: %w", gt.Bin, name, err)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,58 @@
+package handler_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/handler"
+	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+	"github.com/goft-cloud/go-xxl-job-client/v2/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterGlueType(t *testing.T) {
+	handler.RegisterGlueType(handler.GlueType{
+		Name:   "GLUE_TEST_SH",
+		Suffix: ".sh",
+		Bin:    "sh",
+		Env:    map[string]string{"GLUE_ENV": "glue-value"},
+	})
+	assert.NoError(t, handler.SetGlueBin("GLUE_TEST_SH", "sh -e"))
+
+	gt, ok := handler.GetGlueType("GLUE_TEST_SH")
+	assert.True(t, ok)
+	assert.Equal(t, "sh", gt.Bin)
+	assert.Equal(t, []string{"-e"}, gt.Args)
+	assert.Contains(t, handler.GlueTypeNames(), "GLUE_TEST_SH")
+	assert.NotContains(t, handler.CheckGlueTypes(), "GLUE_TEST_SH")
+
+	sh := &handler.ScriptHandler{}
+	jrp, err := sh.ParseJob(&transport.TriggerParam{
+		JobId:          9,
+		LogId:          91,
+		GlueType:       "GLUE_TEST_SH",
+		GlueSource:     "echo \"env: $GLUE_ENV\"\n",
+		GlueUpdatetime: 1,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, sh.Execute(9, "GLUE_TEST_SH", jrp))
+
+	bs, err := ioutil.ReadFile(logger.LogfilePath(91))
+	assert.NoError(t, err)
+	assert.Contains(t, string(bs), "env: glue-value\n")
+
+	// interpreter not found
+	handler.RegisterGlueType(handler.GlueType{Name: "GLUE_NOT_EXISTS", Bin: "not-exists-interpreter"})
+	assert.Contains(t, handler.CheckGlueTypes(), "GLUE_NOT_EXISTS")
+}
+
+func TestScriptHandler_ParseJob_unsupported(t *testing.T) {
+	sh := &handler.ScriptHandler{}
+	_, err := sh.ParseJob(&transport.TriggerParam{
+		JobId:    10,
+		LogId:    101,
+		GlueType: "GLUE_GROOVY",
+	})
+	assert.ErrorIs(t, err, handler.ErrUnsupportedGlueType)
+	assert.Error(t, handler.SetGlueBin("GLUE_GROOVY", "groovy"))
+}
@@ -58,8 +58,8 @@ func (h HttpRequestHandler) Kill(ctx context.Context, r interface{}) (jobId int3
 	return job.JobId, err
 }
 
-// Log fetch
-func (h HttpRequestHandler) Log(ctx context.Context, r interface{}) (log *logger.LogResult, err error) {
+// Log get fetch job logs request
+func (h HttpRequestHandler) Log(ctx context.Context, r interface{}) (logReq *transport.LogRequest, err error) {
 	req := r.(*transport.HttpRequestPkg)
 	lq := &transport.LogRequest{}
 	err = json.Unmarshal(req.Body, lq)
@@ -67,12 +67,6 @@ func (h HttpRequestHandler) Log(ctx context.Context, r interface{}) (log *logger
 		return nil, err
 	}
 
-	line, content := logger.ReadLog(lq.LogDateTim, lq.LogId, lq.FromLineNum)
-	log = &logger.LogResult{
-		FromLineNum: lq.FromLineNum,
-		ToLineNum:   line,
-		LogContent:  content,
-		IsEnd:       true,
-	}
-	return log, err
+	logger.Debug("fetch job logs", "logId", lq.LogId, "fromLine", lq.FromLineNum)
+	return lq, err
 }
@@ -2,6 +2,7 @@ package http
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -32,18 +33,18 @@ func NewHttpMessageHandler(transport *transport.GettyRPCClient, msgHandler func(
 
 // OnOpen session
 func (h *MessageHandler) OnOpen(session getty.Session) error {
-	logger.Infof("Http.OnOpen - session: %s", session.Stat())
+	logger.Info("Http.OnOpen - open session", "session", session.Stat())
 
 	h.GettyClient.AddSession(session)
 	return nil
 }
 
 func (h *MessageHandler) OnError(session getty.Session, err error) {
-	logger.Infof("Http.OnError - session{%s} got error{%v}, will be closed.", session.Stat(), err)
+	logger.Error("Http.OnError - session got error, will be closed", "session", session.Stat(), "error", err)
 }
 
 func (h *MessageHandler) OnClose(session getty.Session) {
-	logger.Infof("Http.OnClose - session{%s} is closing......", session.Stat())
+	logger.Info("Http.OnClose - session is closing......", "session", session.Stat())
 
 	h.GettyClient.RemoveSession(session)
 }
@@ -51,14 +52,14 @@ func (h *MessageHandler) OnClose(session getty.Session) {
 func (h *MessageHandler) OnMessage(session getty.Session, pkg interface{}) {
 	s, ok := pkg.([]*transport.HttpRequestPkg)
 	if !ok {
-		logger.Errorf("Http.OnMessage - illegal package: {%#v}", pkg)
+		logger.Error("Http.OnMessage - illegal package", "pkg", fmt.Sprintf("%#v", pkg))
 		return
 	}
 
 	for _, v := range s {
 		if v != nil {
 			res, err := h.MsgHandle(context.Background(), v)
-			logger.Debugf("Http.OnMessage - reply message package data: %s", string(res))
+			logger.Debug("Http.OnMessage - reply message package data", "data", string(res))
 			reply(session, res, err)
 		}
 	}
@@ -67,14 +68,14 @@ func (h *MessageHandler) OnMessage(session getty.Session, pkg interface{}) {
 func (h *MessageHandler) OnCron(sess getty.Session) {
 	active := sess.GetActive()
 	actDtime := active.Format(constants.DateTimeFormat2)
-	logger.Debugf("Http.OnCron - session heartbeat check, last active: %s", actDtime)
+	logger.Debug("Http.OnCron - session heartbeat check", "lastActive", actDtime)
 
 	if cronTime < time.Since(active).Nanoseconds() {
-		logger.Infof(
-			"Tcp.OnCorn - session{%s} timeout{%s}(last active:%s)",
-			sess.Stat(),
-			time.Since(active).String(),
-			actDtime,
+		logger.Info(
+			"Http.OnCorn - session timeout",
+			"session", sess.Stat(),
+			"timeout", time.Since(active).String(),
+			"lastActive", actDtime,
 		)
 
 		sess.Close()
@@ -84,7 +85,7 @@ func (h *MessageHandler) OnCron(sess getty.Session) {
 
 func reply(sess getty.Session, resp []byte, err error) {
 	if sess.IsClosed() {
-		logger.Errorf("Tcp.OnMessage - reply error: session closed, err: %#v, resp: %s", err, string(resp))
+		logger.Error("Http.OnMessage - reply error: session closed", "error", err, "resp", string(resp))
 		return
 	}
 
@@ -95,6 +96,6 @@ func reply(sess getty.Session, resp []byte, err error) {
 
 	_, _, err = sess.WritePkg(pkg, writePkgTimeout)
 	if err != nil {
-		logger.Errorf("Http.WritePkg error: %#v, pkg: %#v", err, pkg)
+		logger.Error("Http.WritePkg error", "error", err, "pkg", fmt.Sprintf("%#v", pkg))
 	}
 }
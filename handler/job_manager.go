@@ -3,15 +3,32 @@ package handler
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strconv"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/goft-cloud/go-xxl-job-client/v2/constants"
 	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+	"github.com/goft-cloud/go-xxl-job-client/v2/metrics"
+	"github.com/goft-cloud/go-xxl-job-client/v2/param"
 	"github.com/goft-cloud/go-xxl-job-client/v2/queue"
 	"github.com/goft-cloud/go-xxl-job-client/v2/transport"
 )
 
+const (
+	// idleCheckInterval interval for check all jobs is idle on shutdown
+	idleCheckInterval = 100 * time.Millisecond
+	// shutdownKillWait max wait time for the killed tasks return on shutdown
+	shutdownKillWait = 3 * time.Second
+
+	// DefaultIdleCycles the idle JobQueue will be evicted after the idle check cycles. same as java executor
+	DefaultIdleCycles = 30
+	// DefaultIdleInterval interval of the idle check cycle
+	DefaultIdleInterval = 3 * time.Second
+)
+
 // ExecuteHandler interface
 type ExecuteHandler interface {
 	ParseJob(trigger *transport.TriggerParam) (runParam *JobRunParam, err error)
@@ -27,42 +44,211 @@ type JobQueue struct {
 	Run   int32 // 0 stop, 1 run
 	Queue *queue.Queue
 	// GlueType name
-	GlueType   string
-	CurrentJob *JobRunParam
+	GlueType string
+	// handler name for metrics. bean job is ExecutorHandler, script job is GlueType
+	handler string
+	// mu guard the current task, poll task and set current is atomic for killRunning.
+	mu sync.Mutex
+	// current the running task, is nil on the runner idle
+	current *JobRunParam
+	// removed from the JobManager, stop report the queue metrics. guarded by mu
+	removed bool
+	// lifecycle hooks of the bean job, is nil on the handler not implements BeanJobLifecycle
+	lifecycle *jobLifecycle
+	// active mark has new task after last idle check. 1 active
+	active int32
+	// idleCycles the continuous idle check cycles, access with JobManager lock
+	idleCycles int
+	// liveRunners the live runner goroutines counter of JobManager
+	liveRunners *int32
 	// Callback notify admin on job exec completed.
 	Callback func(trigger *JobRunParam, runErr error)
 }
 
+// isRunning check the job has running or waiting task.
+func (jq *JobQueue) isRunning() bool {
+	return atomic.LoadInt32(&jq.Run) > 0 || jq.Queue.HasNext()
+}
+
 // StopJob check
 func (jq *JobQueue) StopJob() bool {
 	return atomic.CompareAndSwapInt32(&jq.Run, 1, 0)
 }
 
-// StartJob run
+// StartJob start the job runner, do nothing on the runner is running.
 func (jq *JobQueue) StartJob() {
 	if atomic.CompareAndSwapInt32(&jq.Run, 0, 1) {
 		jq.asyncRunJob()
 	} else {
-		logger.Error("job not started, the compare and swap JobQueue.Run fail")
+		logger.Debug("job runner is running, the task will run after the waiting tasks", "jobId", jq.JobId)
+	}
+}
+
+// Current get the running task, return nil on no running task.
+func (jq *JobQueue) Current() *JobRunParam {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+
+	return jq.current
+}
+
+// next poll the next task and mark it as current. return false on no more task.
+func (jq *JobQueue) next() (*JobRunParam, bool) {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+
+	jq.current = nil
+	has, node := jq.Queue.Poll()
+	if !has {
+		return nil, false
 	}
+
+	jq.current = node.(*JobRunParam)
+	return jq.current, true
+}
+
+// matches check the trigger is for the same handler of the queue.
+func (jq *JobQueue) matches(ttp *transport.TriggerParam) bool {
+	return jq.GlueType == ttp.GlueType && jq.handler == triggerHandler(ttp)
 }
 
 func (jq *JobQueue) asyncRunJob() {
 	go func() {
+		if jq.liveRunners != nil {
+			reportRunners(atomic.AddInt32(jq.liveRunners, 1))
+			defer func() {
+				reportRunners(atomic.AddInt32(jq.liveRunners, -1))
+			}()
+		}
+
 		for {
-			has, node := jq.Queue.Poll()
+			runParam, has := jq.next()
 			if has {
-				jq.CurrentJob = node.(*JobRunParam)
-				jq.Callback(jq.CurrentJob, jq.Execute(jq.JobId, jq.GlueType, jq.CurrentJob))
-			} else {
-				logger.Error("pull an job node from JobQueue.Queue fail")
-				jq.StopJob()
+				jq.runTask(runParam)
+				continue
+			}
+
+			logger.Debug("no more task in the JobQueue.Queue, stop job runner", "jobId", jq.JobId)
+			jq.StopJob()
+
+			// the task put before Run reset can't start a new runner, continue run it.
+			if !jq.Queue.HasNext() || !atomic.CompareAndSwapInt32(&jq.Run, 0, 1) {
 				break
 			}
 		}
 	}()
 }
 
+// runTask execute the task, then callback the result to admin.
+func (jq *JobQueue) runTask(runParam *JobRunParam) {
+	jq.reportDepth()
+
+	start := time.Now()
+	var err error
+	if reason := runParam.stoppedReason(); reason != "" {
+		// killed after polled, skip execute.
+		err = errors.New(reason)
+	} else if err = jq.lifecycle.init(); err != nil {
+		err = fmt.Errorf("bean job init failed: %w", err)
+		ctx := newJobLogCtx(NewCtxJobParamByJrp(jq.JobId, runParam))
+		logger.LogJobf(ctx, "job#%d task#%d run failed, %s", jq.JobId, runParam.LogId, err.Error())
+	} else {
+		err = jq.Execute(jq.JobId, jq.GlueType, runParam)
+	}
+
+	if reason := runParam.stoppedReason(); reason != "" {
+		err = errors.New(reason)
+		// the job runner is killed
+		jq.lifecycle.destroy(reason)
+	}
+
+	jq.reportResult(runParam, err, start)
+	jq.Callback(runParam, err)
+}
+
+// reportRunners report the live runner goroutines number to metrics
+func reportRunners(n int32) {
+	metrics.Set(metrics.LiveRunners, nil, float64(n))
+}
+
+// reportDepth report the waiting tasks number to metrics
+func (jq *JobQueue) reportDepth() {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+
+	if !jq.removed {
+		metrics.Set(metrics.QueueDepth, jq.depthLabels(), float64(jq.Queue.Len()))
+	}
+}
+
+// markRemoved mark the queue is removed, delete the queue metrics.
+func (jq *JobQueue) markRemoved() {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+
+	jq.removed = true
+	metrics.Delete(metrics.QueueDepth, jq.depthLabels())
+}
+
+func (jq *JobQueue) depthLabels() metrics.Labels {
+	return metrics.Labels{"job_id": strconv.Itoa(int(jq.JobId))}
+}
+
+// reportResult report the task execute result and duration to metrics
+func (jq *JobQueue) reportResult(runParam *JobRunParam, err error, start time.Time) {
+	status := metrics.StatusSuccess
+	if runParam.stoppedReason() != "" {
+		status = metrics.StatusKilled
+	} else if errors.Is(err, ErrJobTimeout) {
+		status = metrics.StatusTimeout
+	} else if err != nil {
+		status = metrics.StatusFail
+	}
+
+	metrics.ObserveDuration(metrics.TaskDuration, metrics.Labels{"handler": jq.handler}, start)
+	metrics.Inc(metrics.TasksTotal, metrics.Labels{"handler": jq.handler, "status": status})
+}
+
+// killRunning kill the running task and discard all waiting tasks of the job.
+// return false on no running task. the Destroy hook will be called after the killed task returned.
+func (jq *JobQueue) killRunning(reason string) bool {
+	// clear the queue and stop the current task with the lock, the runner can't poll a task between them.
+	jq.mu.Lock()
+	discarded := jq.Queue.Clear()
+	current := jq.current
+	stopped := current != nil && current.stop(reason)
+	jq.mu.Unlock()
+
+	// discard waiting tasks, notify admin them are failed.
+	defer jq.reportDepth()
+	for _, item := range discarded {
+		runParam := item.(*JobRunParam)
+		ctx := newJobLogCtx(NewCtxJobParamByJrp(jq.JobId, runParam))
+		logger.LogJobf(ctx, "job#%d task#%d discarded from queue, reason: %s", jq.JobId, runParam.LogId, reason)
+
+		jq.Callback(runParam, errors.New(reason))
+	}
+
+	if current == nil {
+		return false
+	}
+
+	if stopped {
+		ctx := newJobLogCtx(NewCtxJobParamByJrp(jq.JobId, current))
+		logger.LogJobf(ctx, "job#%d task#%d killed, reason: %s", jq.JobId, current.LogId, reason)
+	}
+	return true
+}
+
+// stop kill the tasks and destroy the bean job. the destroy is called by the runner on has running task.
+//
+// must be called without the JobManager lock, the Destroy hook and the callbacks may be slow.
+func (jq *JobQueue) stop(reason string) {
+	if !jq.killRunning(reason) {
+		jq.lifecycle.destroy(reason)
+	}
+}
+
 // JobManager struct
 type JobManager struct {
 	sync.RWMutex
@@ -70,66 +256,166 @@ type JobManager struct {
 	// key is jobName by registered.
 	//
 	// TIP: one jobName corresponds to one jobId
-	jobMap map[string]BeanJobRunFunc
+	jobMap map[string]*beanJob
+	// middlewares the global middlewares for all bean jobs
+	middlewares []BeanJobMiddleware
 	// key is jobId.
 	//
 	// TIP: one jobName corresponds to one jobId
 	QueueMap map[int32]*JobQueue
 	// CallbackFunc call on job task completed, notify xxl-job admin
 	CallbackFunc func(trigger *JobRunParam, runErr error)
+	// closed mark on shutdown, will reject new triggers. 1 closed
+	closed int32
+
+	taskMu sync.Mutex
+	// tasks the not finished(waiting or running) task logIds
+	tasks map[int64]struct{}
+
+	// liveRunners the live job runner goroutines number
+	liveRunners int32
+	// evictStop stop the idle evict loop
+	evictStop chan struct{}
+	evictWg   sync.WaitGroup
+}
+
+// beanJob the registered bean job
+type beanJob struct {
+	runner BeanJobRunner
+	// middlewares of the job, run after the global middlewares.
+	middlewares []BeanJobMiddleware
+	// paramFormat the executor params format hint
+	paramFormat param.Format
+}
+
+// runFunc the run func of the job, keep the registered func for get its name.
+func (bj *beanJob) runFunc() BeanJobRunFunc {
+	if fn, ok := bj.runner.(BeanJobRunFunc); ok {
+		return fn
+	}
+	return bj.runner.Handle
+}
+
+// tag the JobTag of the job tasks. the func job use the func name, the runner job use the runner type.
+func (bj *beanJob) tag() string {
+	if _, ok := bj.runner.(BeanJobRunFunc); ok {
+		return ""
+	}
+
+	return fmt.Sprintf("%T", bj.runner)
 }
 
-// RegisterJob handler
-func (jm *JobManager) RegisterJob(jobName string, beanJobFn BeanJobRunFunc) {
+// RegisterJob handler, can with the job middlewares.
+func (jm *JobManager) RegisterJob(jobName string, beanJobFn BeanJobRunFunc, mws ...BeanJobMiddleware) {
+	jm.RegisterRunner(jobName, beanJobFn, mws...)
+}
+
+// RegisterRunner register the job runner, can with the job middlewares.
+// if the runner implements BeanJobLifecycle, the Init and Destroy will be called per jobId.
+func (jm *JobManager) RegisterRunner(jobName string, runner BeanJobRunner, mws ...BeanJobMiddleware) {
 	jm.Lock()
 	defer jm.Unlock()
 
 	if jm.jobMap == nil {
-		jm.jobMap = make(map[string]BeanJobRunFunc)
+		jm.jobMap = make(map[string]*beanJob)
 	} else if _, ok := jm.jobMap[jobName]; ok {
 		panic("the job had already register, job name can't be repeated:" + jobName)
 	}
 
-	jm.jobMap[jobName] = beanJobFn
+	jm.jobMap[jobName] = &beanJob{runner: runner, middlewares: mws}
+}
+
+// SetParamFormat set the executor params format hint of the registered job.
+// default will detect the format by params content.
+func (jm *JobManager) SetParamFormat(jobName string, format param.Format) {
+	jm.Lock()
+	defer jm.Unlock()
+
+	bj, ok := jm.jobMap[jobName]
+	if !ok {
+		panic("the job is not registered, can't set param format:" + jobName)
+	}
+	bj.paramFormat = format
+}
+
+// Use add global middlewares for all bean jobs.
+//
+// the run order: global middlewares -> job middlewares -> job func
+func (jm *JobManager) Use(mws ...BeanJobMiddleware) {
+	jm.Lock()
+	defer jm.Unlock()
+
+	jm.middlewares = append(jm.middlewares, mws...)
 }
 
 // HasRunning of the jobId
 func (jm *JobManager) HasRunning(jobId int32) bool {
-	qu, has := jm.QueueMap[jobId]
+	qu, has := jm.getQueue(jobId)
 	if has {
-		if qu.Run > 0 || qu.Queue.HasNext() {
-			return true
-		}
+		return qu.isRunning()
 	}
 	return false
 }
 
 // PutJobToQueue push job to queue and run it.
 func (jm *JobManager) PutJobToQueue(ttp *transport.TriggerParam) (err error) {
-	logger.Debugf("put and start job#%d, trigger info: %#v", ttp.JobId, ttp)
+	return jm.putJob(context.Background(), ttp)
+}
 
-	jq, has := jm.QueueMap[ttp.JobId] // map value是地址，读不加锁
-	if has {
-		runParam, err := jq.ParseJob(ttp)
-		if err != nil {
-			return err
-		}
+// putJob push job to queue and run it. the traceCtx carry the run request span.
+func (jm *JobManager) putJob(traceCtx context.Context, ttp *transport.TriggerParam) (err error) {
+	logger.Debug("put and start job", "jobId", ttp.JobId, "logId", ttp.LogId, "trigger", fmt.Sprintf("%#v", ttp))
 
-		err = jq.Queue.Put(runParam)
-		if err == nil {
-			jq.StartJob()
-		}
-		return err
+	// the evicting and shutdown will change the state with the write lock
+	jm.RLock()
+	if jm.isClosed() {
+		jm.RUnlock()
+		return ErrShuttingDown
 	}
 
+	jq, has := jm.QueueMap[ttp.JobId]
+	if has && jq.matches(ttp) {
+		defer jm.RUnlock()
+		return jm.putToQueue(traceCtx, jq, ttp)
+	}
+	jm.RUnlock()
+
+	// stop the removed queue after unlock
+	var removed *JobQueue
+	defer func() {
+		if removed != nil {
+			removed.stop("job handler changed to " + triggerHandler(ttp))
+		}
+	}()
+
 	// 任务map初始化锁
 	jm.Lock()
 	defer jm.Unlock()
 
+	if jm.isClosed() {
+		return ErrShuttingDown
+	}
+
+	jq, has = jm.QueueMap[ttp.JobId]
+	if has {
+		if jq.matches(ttp) {
+			return jm.putToQueue(traceCtx, jq, ttp)
+		}
+
+		// the admin switched the job to another handler or glue type, remove the old queue.
+		logger.Info("the job handler changed, will remove the old job queue", "jobId", ttp.JobId,
+			"oldHandler", jq.handler, "newHandler", triggerHandler(ttp))
+		jm.removeQueue(jq)
+		removed = jq
+	}
+
 	jq = &JobQueue{
-		GlueType: ttp.GlueType,
-		JobId:    ttp.JobId,
-		Callback: jm.CallbackFunc,
+		GlueType:    ttp.GlueType,
+		JobId:       ttp.JobId,
+		Callback:    jm.taskCallback,
+		handler:     triggerHandler(ttp),
+		active:      1,
+		liveRunners: &jm.liveRunners,
 	}
 
 	// switch bean job exec handler.
@@ -138,12 +424,20 @@ func (jm *JobManager) PutJobToQueue(ttp *transport.TriggerParam) (err error) {
 			return errors.New("bean job handler not found")
 		}
 
-		fun, ok := jm.jobMap[ttp.ExecutorHandler]
+		bj, ok := jm.jobMap[ttp.ExecutorHandler]
 		if !ok {
 			return errors.New("bean job handler not found")
 		}
 
-		jq.ExecuteHandler = &BeanHandler{RunFunc: fun}
+		mws := make([]BeanJobMiddleware, 0, len(jm.middlewares)+len(bj.middlewares))
+		mws = append(mws, jm.middlewares...)
+		jq.lifecycle = newJobLifecycle(bj.runner, ttp.JobId, ttp.ExecutorHandler)
+		jq.ExecuteHandler = &BeanHandler{
+			RunFunc:     bj.runFunc(),
+			Tag:         bj.tag(),
+			Middlewares: append(mws, bj.middlewares...),
+			ParamFormat: bj.paramFormat,
+		}
 	} else {
 		// use script handler
 		jq.ExecuteHandler = &ScriptHandler{}
@@ -154,58 +448,333 @@ func (jm *JobManager) PutJobToQueue(ttp *transport.TriggerParam) (err error) {
 		return err
 	}
 
+	runParam.traceCtx = traceCtx
+	jm.markTask(runParam.LogId)
 	jq.Queue = queue.NewQueue()
 	if err = jq.Queue.Put(runParam); err != nil {
+		runParam.finish()
+		jm.unmarkTask(runParam.LogId)
 		return err
 	}
 
+	if jm.QueueMap == nil {
+		jm.QueueMap = make(map[int32]*JobQueue)
+	}
 	jm.QueueMap[ttp.JobId] = jq
 	jq.StartJob()
 	return nil
 }
 
+// putToQueue push the task to the exists job queue
+func (jm *JobManager) putToQueue(traceCtx context.Context, jq *JobQueue, ttp *transport.TriggerParam) error {
+	runParam, err := jq.ParseJob(ttp)
+	if err != nil {
+		return err
+	}
+
+	runParam.traceCtx = traceCtx
+	if err = jm.applyBlockStrategy(jq, ttp); err != nil {
+		runParam.finish()
+		return err
+	}
+
+	jm.markTask(runParam.LogId)
+	err = jq.Queue.Put(runParam)
+	if err != nil {
+		runParam.finish()
+		jm.unmarkTask(runParam.LogId)
+		return err
+	}
+
+	atomic.StoreInt32(&jq.active, 1)
+	jq.reportDepth()
+	jq.StartJob()
+	return nil
+}
+
+// getQueue get the job queue by jobId
+func (jm *JobManager) getQueue(jobId int32) (*JobQueue, bool) {
+	jm.RLock()
+	defer jm.RUnlock()
+
+	jq, ok := jm.QueueMap[jobId]
+	return jq, ok
+}
+
+// removeQueue remove the job queue, must be called with the write lock.
+// the removed queue should be stopped after unlock. see JobQueue.stop()
+func (jm *JobManager) removeQueue(jq *JobQueue) {
+	delete(jm.QueueMap, jq.JobId)
+	jq.markRemoved()
+}
+
+// StartIdleEvict start evict the idle job queues, the queue will be removed
+// after it has no task in the continuous idle cycles. the bean job Destroy hook will be called.
+func (jm *JobManager) StartIdleEvict(cycles int, interval time.Duration) {
+	if cycles <= 0 {
+		return
+	}
+	if interval <= 0 {
+		interval = DefaultIdleInterval
+	}
+
+	jm.Lock()
+	if jm.evictStop != nil {
+		jm.Unlock()
+		return
+	}
+	jm.evictStop = make(chan struct{})
+	stop := jm.evictStop
+	jm.Unlock()
+
+	jm.evictWg.Add(1)
+	go func() {
+		defer jm.evictWg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				jm.evictIdle(cycles)
+			}
+		}
+	}()
+}
+
+// StopIdleEvict stop the idle evict loop
+func (jm *JobManager) StopIdleEvict() {
+	jm.Lock()
+	stop := jm.evictStop
+	jm.evictStop = nil
+	jm.Unlock()
+
+	if stop != nil {
+		close(stop)
+		jm.evictWg.Wait()
+	}
+}
+
+// evictIdle run an idle check cycle, return the evicted number.
+func (jm *JobManager) evictIdle(cycles int) int {
+	var evicted []*JobQueue
+
+	jm.Lock()
+	for _, jq := range jm.QueueMap {
+		if atomic.SwapInt32(&jq.active, 0) == 1 || jq.isRunning() {
+			jq.idleCycles = 0
+			continue
+		}
+
+		jq.idleCycles++
+		if jq.idleCycles < cycles {
+			continue
+		}
+
+		logger.Debug("the job queue is idle, evict it", "jobId", jq.JobId, "handler", jq.handler, "idleCycles", jq.idleCycles)
+		jm.removeQueue(jq)
+		evicted = append(evicted, jq)
+	}
+	jm.Unlock()
+
+	for _, jq := range evicted {
+		jq.stop("job queue evicted by idle")
+	}
+	return len(evicted)
+}
+
+// LiveRunners the number of live job runner goroutines
+func (jm *JobManager) LiveRunners() int {
+	return int(atomic.LoadInt32(&jm.liveRunners))
+}
+
+// applyBlockStrategy handle the new trigger by ExecutorBlockStrategy when the job has running task.
+//
+// - SERIAL_EXECUTION: the new trigger will wait in queue(default)
+// - DISCARD_LATER: the new trigger will be discarded
+// - COVER_EARLY: the running task will be killed, then run the new trigger
+func (jm *JobManager) applyBlockStrategy(jq *JobQueue, ttp *transport.TriggerParam) error {
+	if !jq.isRunning() {
+		return nil
+	}
+
+	ctx := newJobLogCtx(NewCtxJobParamByTpp(ttp))
+	switch ttp.ExecutorBlockStrategy {
+	case constants.BlockDiscardLater:
+		msg := "block strategy effect: Discard Later"
+		logger.LogJobf(ctx, "job#%d task#%d discarded, job has running task. %s", ttp.JobId, ttp.LogId, msg)
+
+		return errors.New(msg)
+	case constants.BlockCoverEarly:
+		msg := "block strategy effect: Cover Early"
+		logger.Info("job has running task, will be killed by new task", "jobId", ttp.JobId, "logId", ttp.LogId)
+		logger.LogJobf(ctx, "job#%d task#%d cover the running task. %s", ttp.JobId, ttp.LogId, msg)
+		jq.killRunning(msg)
+	default:
+		logger.LogJobf(ctx, "job#%d task#%d wait in queue, job has running task. block strategy effect: Serial execution", ttp.JobId, ttp.LogId)
+	}
+	return nil
+}
+
 // cancel job run by admin kill notify
 func (jm *JobManager) cancelJob(jobId int32) {
-	jq, has := jm.QueueMap[jobId]
+	jq, has := jm.getQueue(jobId)
 	if !has {
-		logger.Errorf("cancel job#%d error, job not found", jobId)
+		logger.Error("cancel job failed, job not found", "jobId", jobId)
 		return
 	}
 
-	logger.Infof("the job#%d will be cancel by xxl-job admin notify", jobId)
+	logger.Info("the job will be cancel by xxl-job admin notify", "jobId", jobId)
+	metrics.Inc(metrics.KillsTotal, metrics.Labels{"handler": jq.handler})
 
-	ok := jq.StopJob()
-	if ok {
-		if jq.CurrentJob != nil && jq.CurrentJob.CurrentCancelFunc != nil {
-			jq.CurrentJob.CurrentCancelFunc()
+	if jq.Current() == nil {
+		logger.Error("cancel job failed, current running task not found", "jobId", jobId)
+	}
 
-			go func() {
-				logId := jq.CurrentJob.LogId
+	jq.stop("job killed by xxl-job admin")
+}
 
-				cjp := NewCtxJobParamByJrp(jobId, jq.CurrentJob)
-				ctx := context.WithValue(context.Background(), constants.CtxParamKey, cjp)
+// IsTaskRunning check the task is waiting or running.
+func (jm *JobManager) IsTaskRunning(logId int64) bool {
+	jm.taskMu.Lock()
+	defer jm.taskMu.Unlock()
 
-				logger.LogJobf(ctx, "job#%d task#%d canceled by admin!", jobId, logId)
-			}()
-		} else {
-			logger.Errorf("cancel job#%d error, current running task not found", jobId)
+	_, ok := jm.tasks[logId]
+	return ok
+}
+
+func (jm *JobManager) markTask(logId int64) {
+	jm.taskMu.Lock()
+	defer jm.taskMu.Unlock()
+
+	if jm.tasks == nil {
+		jm.tasks = make(map[int64]struct{})
+	}
+	jm.tasks[logId] = struct{}{}
+}
+
+func (jm *JobManager) unmarkTask(logId int64) {
+	jm.taskMu.Lock()
+	defer jm.taskMu.Unlock()
+
+	delete(jm.tasks, logId)
+}
+
+// taskCallback mark the task finished, then notify xxl-job admin
+func (jm *JobManager) taskCallback(runParam *JobRunParam, runErr error) {
+	runParam.finish()
+	jm.unmarkTask(runParam.LogId)
+	jm.CallbackFunc(runParam, runErr)
+}
+
+// Shutdown stop accept new triggers and wait the running tasks completed.
+// if ctx is done before that, will kill all running tasks.
+func (jm *JobManager) Shutdown(ctx context.Context) error {
+	// with the write lock, the putting tasks are all in queue after it.
+	jm.Lock()
+	atomic.StoreInt32(&jm.closed, 1)
+	jm.Unlock()
+	jm.StopIdleEvict()
+
+	// destroy the bean jobs after all tasks returned
+	defer jm.destroyAll("executor shutdown")
+
+	if jm.waitIdle(ctx) {
+		return nil
+	}
+
+	logger.Error("wait running tasks completed failed, will kill them", "error", ctx.Err())
+	for _, jq := range jm.queues() {
+		jq.killRunning("job killed by executor shutdown")
+	}
+
+	// wait the killed tasks return and callback to admin
+	killCtx, cancel := context.WithTimeout(context.Background(), shutdownKillWait)
+	defer cancel()
+
+	if !jm.waitIdle(killCtx) {
+		logger.Error("wait killed tasks return timeout, exit now")
+	}
+	return ctx.Err()
+}
+
+// waitIdle wait all jobs no running task. return false on ctx done.
+func (jm *JobManager) waitIdle(ctx context.Context) bool {
+	ticker := time.NewTicker(idleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if !jm.hasAnyRunning() {
+			return true
 		}
 
-		if jq.Queue != nil {
-			jq.Queue.Clear()
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
 		}
 	}
 }
 
+// destroyAll call the Destroy hook of all bean jobs. skip the jobs still have running task.
+func (jm *JobManager) destroyAll(reason string) {
+	for _, jq := range jm.queues() {
+		if jq.isRunning() {
+			logger.Warn("the job still has running task, skip destroy it", "jobId", jq.JobId, "handler", jq.handler)
+			continue
+		}
+		jq.lifecycle.destroy(reason)
+	}
+}
+
+func (jm *JobManager) hasAnyRunning() bool {
+	for _, jq := range jm.queues() {
+		if jq.isRunning() {
+			return true
+		}
+	}
+	return false
+}
+
+func (jm *JobManager) queues() []*JobQueue {
+	jm.RLock()
+	defer jm.RUnlock()
+
+	ls := make([]*JobQueue, 0, len(jm.QueueMap))
+	for _, jq := range jm.QueueMap {
+		ls = append(ls, jq)
+	}
+	return ls
+}
+
+// triggerHandler get the handler name of trigger. bean job is ExecutorHandler, script job is GlueType
+func triggerHandler(ttp *transport.TriggerParam) string {
+	if ttp.ExecutorHandler != "" {
+		return ttp.ExecutorHandler
+	}
+	return ttp.GlueType
+}
+
 // BeanJobLength size
 func (jm *JobManager) BeanJobLength() int {
-	if jm.jobMap == nil {
-		return 0
-	}
+	jm.RLock()
+	defer jm.RUnlock()
+
 	return len(jm.jobMap)
 }
 
+// isClosed check the manager is shutdown
+func (jm *JobManager) isClosed() bool {
+	return atomic.LoadInt32(&jm.closed) == 1
+}
+
 func (jm *JobManager) clearJob() {
-	jm.jobMap = map[string]BeanJobRunFunc{}
+	jm.Lock()
+	defer jm.Unlock()
+
+	jm.jobMap = map[string]*beanJob{}
 	jm.QueueMap = make(map[int32]*JobQueue)
 }
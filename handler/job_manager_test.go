@@ -0,0 +1,392 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/admin"
+	"github.com/goft-cloud/go-xxl-job-client/v2/constants"
+	"github.com/goft-cloud/go-xxl-job-client/v2/executor"
+	"github.com/goft-cloud/go-xxl-job-client/v2/handler"
+	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+	"github.com/goft-cloud/go-xxl-job-client/v2/metrics"
+	"github.com/goft-cloud/go-xxl-job-client/v2/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobManager_handlerChanged(t *testing.T) {
+	done := make(chan error, 10)
+	jm := &handler.JobManager{
+		QueueMap: make(map[int32]*handler.JobQueue),
+		CallbackFunc: func(trigger *handler.JobRunParam, runErr error) {
+			done <- runErr
+		},
+	}
+
+	var ran string
+	jm.RegisterJob("job_a", func(ctx context.Context) error {
+		ran = "job_a"
+		return nil
+	})
+	jm.RegisterJob("job_b", func(ctx context.Context) error {
+		ran = "job_b"
+		return nil
+	})
+
+	assert.NoError(t, jm.PutJobToQueue(&transport.TriggerParam{JobId: 16, LogId: 161, ExecutorHandler: "job_a"}))
+	assert.NoError(t, <-done)
+	assert.Equal(t, "job_a", ran)
+
+	// the admin switched the handler
+	assert.NoError(t, jm.PutJobToQueue(&transport.TriggerParam{JobId: 16, LogId: 162, ExecutorHandler: "job_b"}))
+	assert.NoError(t, <-done)
+	assert.Equal(t, "job_b", ran)
+
+	assert.Eventually(t, func() bool {
+		return jm.LiveRunners() == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestJobManager_StartIdleEvict(t *testing.T) {
+	done := make(chan error, 10)
+	jm := &handler.JobManager{
+		QueueMap: make(map[int32]*handler.JobQueue),
+		CallbackFunc: func(trigger *handler.JobRunParam, runErr error) {
+			done <- runErr
+		},
+	}
+
+	reg := metrics.NewRegistry()
+	metrics.SetRecorder(reg)
+	defer metrics.SetRecorder(nil)
+
+	job := &lifecycleJob{}
+	jm.RegisterRunner("idle_job", job)
+	jm.StartIdleEvict(2, 20*time.Millisecond)
+	defer jm.Shutdown(context.Background())
+
+	assert.NoError(t, jm.PutJobToQueue(&transport.TriggerParam{JobId: 17, LogId: 171, ExecutorHandler: "idle_job"}))
+	assert.NoError(t, <-done)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&job.inits))
+	assert.Contains(t, metricsText(t, reg), `xxl_job_queue_depth{job_id="17"}`)
+
+	// evicted and destroyed
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&job.destroys) == 1
+	}, time.Second, 10*time.Millisecond)
+	// the queue metrics is deleted
+	assert.NotContains(t, metricsText(t, reg), `xxl_job_queue_depth{job_id="17"}`)
+
+	// init again on new trigger
+	assert.NoError(t, jm.PutJobToQueue(&transport.TriggerParam{JobId: 17, LogId: 172, ExecutorHandler: "idle_job"}))
+	assert.NoError(t, <-done)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&job.inits))
+}
+
+// run with: go test -race -run TestJobManager_concurrent ./handler
+func TestJobManager_concurrent(t *testing.T) {
+	var mu sync.Mutex
+	callbacks := make(map[int64]int)
+	jm := &handler.JobManager{
+		CallbackFunc: func(trigger *handler.JobRunParam, runErr error) {
+			mu.Lock()
+			callbacks[trigger.LogId]++
+			mu.Unlock()
+		},
+	}
+
+	sleepJob := func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Millisecond):
+			return nil
+		}
+	}
+	jm.RegisterJob("race_a", sleepJob)
+	jm.RegisterRunner("race_b", &lifecycleJob{})
+	jm.StartIdleEvict(1, time.Millisecond)
+
+	strategies := []string{constants.BlockSerialExecution, constants.BlockDiscardLater, constants.BlockCoverEarly}
+	handlers := []string{"race_a", "race_b"}
+
+	var (
+		wg     sync.WaitGroup
+		logId  int64
+		puts   sync.Map
+		stopCh = make(chan struct{})
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for n := 0; n < 50; n++ {
+				id := atomic.AddInt64(&logId, 1)
+				err := jm.PutJobToQueue(&transport.TriggerParam{
+					JobId:                 int32(n % 3),
+					LogId:                 id,
+					ExecutorHandler:       handlers[(i+n)%2],
+					ExecutorBlockStrategy: strategies[n%3],
+				})
+				if err == nil {
+					puts.Store(id, true)
+				}
+			}
+		}(i)
+	}
+
+	// idle beat requests
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stopCh:
+				return
+			default:
+				jm.HasRunning(int32(rand.Intn(3)))
+				jm.IsTaskRunning(rand.Int63n(400))
+				jm.LiveRunners()
+				jm.BeanJobLength()
+			}
+		}
+	}()
+
+	// kill requests by admin
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-time.After(time.Millisecond):
+				jm.CancelJob(int32(rand.Intn(3)))
+			}
+		}
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	close(stopCh)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	assert.NoError(t, jm.Shutdown(ctx))
+	wg.Wait()
+
+	// reject after shutdown
+	err := jm.PutJobToQueue(&transport.TriggerParam{JobId: 1, LogId: 999, ExecutorHandler: "race_a"})
+	assert.Equal(t, handler.ErrShuttingDown, err)
+	assert.Equal(t, 0, jm.LiveRunners())
+
+	// all accepted tasks callback once
+	mu.Lock()
+	defer mu.Unlock()
+	puts.Range(func(key, _ interface{}) bool {
+		assert.Equal(t, 1, callbacks[key.(int64)], "logId %d", key)
+		assert.False(t, jm.IsTaskRunning(key.(int64)))
+		return true
+	})
+}
+
+type slowDestroyJob struct {
+	destroying chan struct{}
+	release    chan struct{}
+}
+
+func (j *slowDestroyJob) Init(ctx context.Context) error { return nil }
+
+func (j *slowDestroyJob) Destroy(ctx context.Context) error {
+	close(j.destroying)
+	<-j.release
+	return nil
+}
+
+func (j *slowDestroyJob) Handle(ctx context.Context) error { return nil }
+
+func TestJobManager_slowDestroy(t *testing.T) {
+	done := make(chan error, 10)
+	jm := &handler.JobManager{
+		CallbackFunc: func(trigger *handler.JobRunParam, runErr error) {
+			done <- runErr
+		},
+	}
+
+	job := &slowDestroyJob{destroying: make(chan struct{}), release: make(chan struct{})}
+	jm.RegisterRunner("slow_destroy", job)
+	jm.RegisterJob("other", func(ctx context.Context) error { return nil })
+
+	assert.NoError(t, jm.PutJobToQueue(&transport.TriggerParam{JobId: 18, LogId: 181, ExecutorHandler: "slow_destroy"}))
+	assert.NoError(t, <-done)
+
+	// the handler changed, the old job will be destroyed
+	go func() {
+		_ = jm.PutJobToQueue(&transport.TriggerParam{JobId: 18, LogId: 182, ExecutorHandler: "other"})
+	}()
+	<-job.destroying
+
+	// the Destroy hook does not block other requests
+	assert.NoError(t, jm.PutJobToQueue(&transport.TriggerParam{JobId: 19, LogId: 191, ExecutorHandler: "other"}))
+	assert.NoError(t, <-done)
+	jm.HasRunning(18)
+
+	close(job.release)
+	assert.NoError(t, <-done)
+}
+
+// newCallbackProcess create a request process with a fake admin, it records the job callbacks.
+func newCallbackProcess(t *testing.T) (*handler.RequestProcess, <-chan *transport.HandleCallbackParam) {
+	callbacks := make(chan *transport.HandleCallbackParam, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params []*transport.HandleCallbackParam
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&params))
+		for _, p := range params {
+			callbacks <- p
+		}
+		_, _ = w.Write([]byte(`{"code":200}`))
+	}))
+
+	as := admin.NewAdminServer([]string{srv.URL}, time.Second, 10*time.Second, executor.NewExecutor("", "test-app", 0))
+	as.StartCallback()
+	t.Cleanup(func() {
+		_ = as.StopCallback(context.Background())
+		srv.Close()
+	})
+
+	return handler.NewRequestProcess(as, nil), callbacks
+}
+
+// blockingJob the first run will block until release or killed, the later runs return at once.
+type blockingJob struct {
+	runs    int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingJob() *blockingJob {
+	return &blockingJob{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (j *blockingJob) run(ctx context.Context) error {
+	if atomic.AddInt32(&j.runs, 1) > 1 {
+		return nil
+	}
+
+	close(j.started)
+	select {
+	case <-j.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func metricsText(t *testing.T, reg *metrics.Registry) string {
+	buf := new(bytes.Buffer)
+	_, err := reg.WriteTo(buf)
+	assert.NoError(t, err)
+	return buf.String()
+}
+
+func jobLog(t *testing.T, logId int64) string {
+	bs, err := ioutil.ReadFile(logger.LogfilePath(logId))
+	assert.NoError(t, err)
+	return string(bs)
+}
+
+func TestJobManager_blockDiscardLater(t *testing.T) {
+	rp, callbacks := newCallbackProcess(t)
+	job := newBlockingJob()
+	rp.RegisterJob("discard_job", job.run)
+
+	trigger := func(logId int64) *transport.TriggerParam {
+		return &transport.TriggerParam{JobId: 21, LogId: logId, ExecutorHandler: "discard_job", ExecutorBlockStrategy: constants.BlockDiscardLater}
+	}
+	rp.PushJob(trigger(211))
+	<-job.started
+
+	// rejected while the task is running
+	rp.PushJob(trigger(212))
+	cb := <-callbacks
+	assert.Equal(t, int64(212), cb.LogId)
+	assert.Equal(t, int32(http.StatusInternalServerError), cb.ExecuteResult.Code)
+	assert.Contains(t, cb.ExecuteResult.Content, "Discard Later")
+	assert.Contains(t, jobLog(t, 212), "job#21 task#212 discarded, job has running task")
+
+	close(job.release)
+	cb = <-callbacks
+	assert.Equal(t, int64(211), cb.LogId)
+	assert.Equal(t, int32(http.StatusOK), cb.ExecuteResult.Code)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&job.runs))
+}
+
+func TestJobManager_blockCoverEarly(t *testing.T) {
+	rp, callbacks := newCallbackProcess(t)
+	job := newBlockingJob()
+	rp.RegisterJob("cover_job", job.run)
+
+	trigger := func(logId int64) *transport.TriggerParam {
+		return &transport.TriggerParam{JobId: 22, LogId: logId, ExecutorHandler: "cover_job", ExecutorBlockStrategy: constants.BlockCoverEarly}
+	}
+	rp.PushJob(trigger(221))
+	<-job.started
+
+	// the running task is killed, then run the new task
+	rp.PushJob(trigger(222))
+	cb := <-callbacks
+	assert.Equal(t, int64(221), cb.LogId)
+	assert.Equal(t, int32(http.StatusInternalServerError), cb.ExecuteResult.Code)
+	assert.Contains(t, cb.ExecuteResult.Content, "Cover Early")
+
+	cb = <-callbacks
+	assert.Equal(t, int64(222), cb.LogId)
+	assert.Equal(t, int32(http.StatusOK), cb.ExecuteResult.Code)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&job.runs))
+
+	assert.Contains(t, jobLog(t, 221), "job#22 task#221 killed, reason: block strategy effect: Cover Early")
+	assert.Contains(t, jobLog(t, 222), "job#22 task#222 cover the running task")
+}
+
+func TestJobManager_blockSerialExecution(t *testing.T) {
+	rp, callbacks := newCallbackProcess(t)
+	job := newBlockingJob()
+
+	var mu sync.Mutex
+	var order []int64
+	rp.RegisterJob("serial_job", func(ctx context.Context) error {
+		cjp, _ := handler.GetCtxJobParam(ctx)
+		mu.Lock()
+		order = append(order, cjp.LogID)
+		mu.Unlock()
+		return job.run(ctx)
+	})
+
+	for _, logId := range []int64{231, 232, 233} {
+		rp.PushJob(&transport.TriggerParam{JobId: 23, LogId: logId, ExecutorHandler: "serial_job", ExecutorBlockStrategy: constants.BlockSerialExecution})
+		if logId == 231 {
+			<-job.started
+		}
+	}
+
+	close(job.release)
+	for _, logId := range []int64{231, 232, 233} {
+		cb := <-callbacks
+		assert.Equal(t, logId, cb.LogId)
+		assert.Equal(t, int32(http.StatusOK), cb.ExecuteResult.Code)
+	}
+
+	mu.Lock()
+	assert.Equal(t, []int64{231, 232, 233}, order)
+	mu.Unlock()
+	assert.Contains(t, jobLog(t, 232), "job#23 task#232 wait in queue, job has running task")
+	assert.Contains(t, jobLog(t, 233), "job#23 task#233 wait in queue, job has running task")
+}
@@ -5,7 +5,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strings"
+	"sync"
+	"time"
 
 	"github.com/goft-cloud/go-xxl-job-client/v2/constants"
 	"github.com/goft-cloud/go-xxl-job-client/v2/param"
@@ -27,6 +28,11 @@ func GetCtxJobParam(ctx context.Context) (*param.CtxJobParam, error) {
 	return nil, errors.New("ctx job param is invalid")
 }
 
+// newJobLogCtx create context with job param, use for write job task logs.
+func newJobLogCtx(cjp *param.CtxJobParam) context.Context {
+	return context.WithValue(context.Background(), constants.CtxParamKey, cjp)
+}
+
 // NewCtxJobParamByTpp create
 func NewCtxJobParamByTpp(ttp *transport.TriggerParam) *param.CtxJobParam {
 	return &param.CtxJobParam{
@@ -51,9 +57,13 @@ func NewCtxJobParamByJrp(jobId int32, jrp *JobRunParam) *param.CtxJobParam {
 		// user input param string.
 		InputParam:  jrp.InputParam["fullParam"],
 		InputParams: jrp.InputParam,
+		Format:      jrp.ParamFormat,
+		Data:        jrp.InputData,
+		Values:      jrp.InputValues,
 		// sharding params
 		ShardIndex: jrp.ShardIdx,
 		ShardTotal: jrp.ShardTotal,
+		Result:     jrp.result,
 	}
 }
 
@@ -66,19 +76,138 @@ type JobRunParam struct {
 	JobTag string
 	// InputParam user input params, key 'fullParam' always exists.
 	InputParam map[string]string
-	ShardIdx   int32
-	ShardTotal int32
-	// CurrentCancelFunc use for kill running job
-	CurrentCancelFunc context.CancelFunc
+	// InputData the structured data on input param is JSON or YAML
+	InputData map[string]interface{}
+	// InputValues the parsed values on input param is key=value lines
+	InputValues param.Values
+	// ParamFormat of the input param
+	ParamFormat param.Format
+	ShardIdx    int32
+	ShardTotal  int32
+	// Timeout the task execute timeout, from TriggerParam.ExecutorTimeout. 0 is not limit.
+	Timeout time.Duration
+	// mu guard the cancel and stopReason, them are accessed by the job runner and kill requests.
+	mu sync.Mutex
+	// cancel use for kill the running task
+	cancel context.CancelFunc
+	// stopReason the task is killed by admin or block strategy.
+	stopReason string
+	// traceCtx carry the run request span, is parent of the execute spans.
+	traceCtx context.Context
+	// result the handle result reported by the bean job handler
+	result *param.HandleResult
+	// release the resources referenced by the task, eg: the glue script. guarded by mu
+	release func()
 }
 
 // NewJobRunParam create
 func NewJobRunParam(ttp *transport.TriggerParam) *JobRunParam {
-	return &JobRunParam{
+	jrp := &JobRunParam{
 		LogId:       ttp.LogId,
 		LogDateTime: ttp.LogDateTime,
 		JobName:     ttp.ExecutorHandler,
+		Timeout:     time.Duration(ttp.ExecutorTimeout) * time.Second,
+		result:      &param.HandleResult{},
+	}
+
+	// sharding info of the broadcast job
+	if ttp.BroadcastTotal > 0 {
+		jrp.ShardIdx = ttp.BroadcastIndex
+		jrp.ShardTotal = ttp.BroadcastTotal
 	}
+	return jrp
+}
+
+// resultError get the error on the handler reported a failed result
+func (jrp *JobRunParam) resultError() error {
+	code, msg, ok := jrp.result.Get()
+	if !ok || code == constants.HandleCodeSuccess {
+		return nil
+	}
+	return &HandleError{Code: code, Msg: msg}
+}
+
+// resultMsg get the handle message reported by the handler
+func (jrp *JobRunParam) resultMsg() string {
+	_, msg, _ := jrp.result.Get()
+	return msg
+}
+
+// traceContext get the context carry the run request span
+func (jrp *JobRunParam) traceContext() context.Context {
+	if jrp.traceCtx == nil {
+		return context.Background()
+	}
+	return jrp.traceCtx
+}
+
+// newRunContext create context for run the task, will with deadline if Timeout > 0
+func (jrp *JobRunParam) newRunContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if jrp.Timeout > 0 {
+		return context.WithTimeout(parent, jrp.Timeout)
+	}
+	return context.WithCancel(parent)
+}
+
+// setCancel set the cancel func of the running task.
+// the task killed before it started will be canceled at once.
+func (jrp *JobRunParam) setCancel(cancel context.CancelFunc) {
+	jrp.mu.Lock()
+	defer jrp.mu.Unlock()
+
+	jrp.cancel = cancel
+	if jrp.stopReason != "" {
+		cancel()
+	}
+}
+
+// stop mark the task killed with reason and cancel it. return false on already stopped.
+func (jrp *JobRunParam) stop(reason string) bool {
+	jrp.mu.Lock()
+	defer jrp.mu.Unlock()
+
+	if jrp.stopReason != "" {
+		return false
+	}
+
+	jrp.stopReason = reason
+	if jrp.cancel != nil {
+		jrp.cancel()
+	}
+	return true
+}
+
+// CurrentCancelFunc kill the running task.
+//
+// Deprecated: it was the cancel func field, which is not safe for concurrent use.
+// now is a method call the kill, the task cancel is managed by the job runner.
+func (jrp *JobRunParam) CurrentCancelFunc() {
+	jrp.stop("job killed by CurrentCancelFunc")
+}
+
+// stoppedReason get the kill reason, is empty on the task not killed.
+func (jrp *JobRunParam) stoppedReason() string {
+	jrp.mu.Lock()
+	defer jrp.mu.Unlock()
+
+	return jrp.stopReason
+}
+
+// finish release the resources referenced by the task, on it is completed or rejected. only call once.
+func (jrp *JobRunParam) finish() {
+	jrp.mu.Lock()
+	release := jrp.release
+	jrp.release = nil
+	jrp.mu.Unlock()
+
+	if release != nil {
+		release()
+	}
+}
+
+// timeoutError build
+func (jrp *JobRunParam) timeoutError() error {
+	return fmt.Errorf("%w(%s)", ErrJobTimeout, jrp.Timeout)
 }
 
 // WithOptionFn for config param
@@ -87,22 +216,25 @@ func (jrp *JobRunParam) WithOptionFn(fn func(jrp *JobRunParam)) *JobRunParam {
 	return jrp
 }
 
+// inputLines parse the full input param to lines. see param.ParseLines()
+func (jrp *JobRunParam) inputLines() []string {
+	ps := jrp.InputParam["fullParam"]
+	lines, err := param.ParseLines(ps)
+	if err != nil {
+		// fallback split by newline
+		lines = strutil.Split(ps, "\n")
+	}
+	return lines
+}
+
 // BuildCmdArgs list
 func (jrp *JobRunParam) BuildCmdArgs(logfile ...string) []string {
 	var cmdArgs = []string{jrp.JobTag}
 
-	if len(jrp.InputParam) > 0 {
-		ps, ok := jrp.InputParam["fullParam"]
-		if ok {
-			// 参数可用换行隔开
-			params := strings.Split(ps, "\n")
-			for _, v := range params {
-				cmdArgs = append(cmdArgs, strings.TrimSpace(v))
-			}
-		}
-	}
+	// 参数可用换行隔开
+	cmdArgs = append(cmdArgs, jrp.inputLines()...)
 
-	if jrp.ShardTotal > 0 {
+	if jrp.ShardTotal > 0 && !GetScriptOptions().DisableShardArgs {
 		cmdArgs = append(cmdArgs, strutil.MustString(jrp.ShardIdx), strutil.MustString(jrp.ShardTotal))
 	}
 
@@ -122,19 +254,13 @@ func (jrp *JobRunParam) BuildCmdArgsString(logfile string) string {
 	// set job script file
 	buffer.WriteString(jrp.JobTag)
 
-	if len(jrp.InputParam) > 0 {
-		ps, ok := jrp.InputParam["fullParam"]
-		if ok {
-			// 参数可用空格或者换行隔开
-			params := strings.Split(ps, "\n")
-			for _, v := range params {
-				buffer.WriteString(" ")
-				buffer.WriteString(strings.TrimSpace(v))
-			}
-		}
+	// 参数可用空格或者换行隔开
+	for _, v := range jrp.inputLines() {
+		buffer.WriteString(" ")
+		buffer.WriteString(v)
 	}
 
-	if jrp.ShardTotal > 0 {
+	if jrp.ShardTotal > 0 && !GetScriptOptions().DisableShardArgs {
 		buffer.WriteString(fmt.Sprintf(" %d %d", jrp.ShardIdx, jrp.ShardTotal))
 	}
 
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// limitsEnv the ENV marker of the re-exec helper, the value is the limits spec. see execLimited()
+	limitsEnv = "XXL_JOB_RESOURCE_LIMITS"
+	// limitsHelper the argv[0] of the re-exec helper
+	limitsHelper = "xxl-job-limits"
+)
+
+func init() {
+	if spec, ok := os.LookupEnv(limitsEnv); ok {
+		execLimited(spec)
+	}
+}
+
+// limitCmd run the command by the re-exec helper: the executor itself started with the limitsEnv,
+// it sets the resource limits by setrlimit then exec the command.
+// the limits are applied before the command started, also inherited by its child processes.
+//
+// eg: /proc/self/exe(xxl-job-limits) /usr/bin/bash bash script.sh
+func limitCmd(cmd *exec.Cmd, l ResourceLimits) error {
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("resolve the executor path failed: %w", err)
+	}
+
+	path := cmd.Path
+	if !strings.Contains(path, "/") {
+		lp, err := exec.LookPath(path)
+		if err != nil {
+			return err
+		}
+		path = lp
+	}
+
+	var cpu uint64
+	if l.CPUTime > 0 {
+		cpu = uint64(l.CPUTime.Seconds())
+		if cpu == 0 {
+			cpu = 1
+		}
+	}
+
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	cmd.Env = append(env, fmt.Sprintf("%s=%d,%d,%d", limitsEnv, cpu, l.Memory, l.OpenFiles))
+	cmd.Args = append([]string{limitsHelper, path}, cmd.Args...)
+	cmd.Path = self
+	return nil
+}
+
+// execLimited the re-exec helper, set the limits of the spec then exec the command. never returns.
+//
+// the spec is "cpu,memory,nofile", 0 is not limit. os.Args is [limitsHelper, path, argv...]
+func execLimited(spec string) {
+	var cpu, memory, nofile uint64
+	_, err := fmt.Sscanf(spec, "%d,%d,%d", &cpu, &memory, &nofile)
+	if err == nil && len(os.Args) < 3 {
+		err = errors.New("missing the command")
+	}
+
+	if err == nil {
+		env := make([]string, 0, len(os.Environ()))
+		for _, kv := range os.Environ() {
+			if !strings.HasPrefix(kv, limitsEnv+"=") {
+				env = append(env, kv)
+			}
+		}
+
+		// soft limit send SIGXCPU, the hard limit send SIGKILL.
+		// set the memory limit at last, the runtime can't allocate much memory after it.
+		if cpu > 0 {
+			err = setLimit(unix.RLIMIT_CPU, cpu, cpu+1)
+		}
+		if err == nil && nofile > 0 {
+			err = setLimit(unix.RLIMIT_NOFILE, nofile, nofile)
+		}
+		if err == nil && memory > 0 {
+			err = setLimit(unix.RLIMIT_AS, memory, memory)
+		}
+		if err == nil {
+			err = syscall.Exec(os.Args[1], os.Args[2:], env)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: %s\n", limitsHelper, err)
+	os.Exit(127)
+}
+
+// setLimit set the resource limit of the current process.
+// the values will not exceed the current hard limit, it can't be raised without privilege.
+func setLimit(resource int, soft, hard uint64) error {
+	var rl unix.Rlimit
+	if err := unix.Getrlimit(resource, &rl); err != nil {
+		return err
+	}
+
+	if rl.Max != unix.RLIM_INFINITY && hard > rl.Max {
+		hard = rl.Max
+	}
+	if soft > hard {
+		soft = hard
+	}
+
+	rl.Cur, rl.Max = soft, hard
+	return unix.Setrlimit(resource, &rl)
+}
+
+// limitExitReason check the process is exited by exceeded the limits, return the reason message.
+func limitExitReason(err error, l ResourceLimits) string {
+	var ee *exec.ExitError
+	if l.IsEmpty() || !errors.As(err, &ee) {
+		return ""
+	}
+
+	ws, ok := ee.Sys().(syscall.WaitStatus)
+	if !ok || !ws.Signaled() {
+		return ""
+	}
+
+	switch ws.Signal() {
+	case syscall.SIGXCPU:
+		return fmt.Sprintf("exceeded the CPU time limit %s", l.CPUTime)
+	case syscall.SIGKILL:
+		if l.CPUTime > 0 && ee.UserTime()+ee.SystemTime() >= l.CPUTime {
+			return fmt.Sprintf("exceeded the CPU time limit %s", l.CPUTime)
+		}
+	case syscall.SIGSEGV, syscall.SIGABRT, syscall.SIGBUS:
+		if l.Memory > 0 {
+			return fmt.Sprintf("may be exceeded the memory limit %d bytes", l.Memory)
+		}
+	}
+	return ""
+}
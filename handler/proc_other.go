@@ -0,0 +1,19 @@
+//go:build !linux
+// +build !linux
+
+package handler
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// limitCmd only supported on linux
+func limitCmd(_ *exec.Cmd, _ ResourceLimits) error {
+	return errors.New("the resource limits only supported on linux")
+}
+
+// limitExitReason only supported on linux
+func limitExitReason(_ error, _ ResourceLimits) string {
+	return ""
+}
@@ -0,0 +1,36 @@
+//go:build !windows
+// +build !windows
+
+package handler
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup start the command in a new process group, so can kill the whole process tree.
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// setCredential run the process as the user and group
+func setCredential(cmd *exec.Cmd, c *Credential) error {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Credential = &syscall.Credential{Uid: c.Uid, Gid: c.Gid}
+	return nil
+}
+
+// terminateProcess send SIGTERM to the process group
+func terminateProcess(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+}
+
+// killProcess send SIGKILL to the process group
+func killProcess(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,27 @@
+//go:build windows
+// +build windows
+
+package handler
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// setProcessGroup not support on windows
+func setProcessGroup(_ *exec.Cmd) {}
+
+// setCredential not support on windows
+func setCredential(_ *exec.Cmd, _ *Credential) error {
+	return errors.New("run the script as other user is not supported on windows")
+}
+
+// terminateProcess on windows, kill the process directly
+func terminateProcess(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+// killProcess kill the process
+func killProcess(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
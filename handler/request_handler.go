@@ -3,7 +3,6 @@ package handler
 import (
 	"context"
 
-	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
 	"github.com/goft-cloud/go-xxl-job-client/v2/transport"
 )
 
@@ -16,6 +15,6 @@ type RequestHandler interface {
 	// Run collect and build trigger params from r
 	Run(ctx context.Context, r interface{}) (triggerParam *transport.TriggerParam, err error)
 	Kill(ctx context.Context, r interface{}) (jobId int32, err error)
-	// Log fetch job logs
-	Log(ctx context.Context, r interface{}) (log *logger.LogResult, err error)
+	// Log collect the fetch job logs request params from r
+	Log(ctx context.Context, r interface{}) (logReq *transport.LogRequest, err error)
 }
@@ -3,12 +3,16 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"sync"
+	"unicode/utf8"
 
 	hessian "github.com/apache/dubbo-go-hessian2"
 	"github.com/goft-cloud/go-xxl-job-client/v2/admin"
 	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+	"github.com/goft-cloud/go-xxl-job-client/v2/metrics"
+	"github.com/goft-cloud/go-xxl-job-client/v2/tracing"
 	"github.com/goft-cloud/go-xxl-job-client/v2/transport"
 )
 
@@ -19,6 +23,9 @@ const (
 	MthBeat = "beat"
 
 	MthIdleBeat = "idleBeat"
+
+	// callbackMsgMaxLen max length of the error message in callback
+	callbackMsgMaxLen = 1024
 )
 
 // RequestProcess struct
@@ -28,6 +35,12 @@ type RequestProcess struct {
 	JobManager  *JobManager
 	ReqHandler  RequestHandler
 	adminServer *admin.XxlAdminServer
+	// closed mark on shutdown, will reject new run requests.
+	closed bool
+	// registered to xxl-job admin
+	registered bool
+	// wait the pushing job goroutines on shutdown
+	pushWg sync.WaitGroup
 }
 
 // NewRequestProcess create object.
@@ -47,8 +60,13 @@ func NewRequestProcess(adminServer *admin.XxlAdminServer, handler RequestHandler
 }
 
 // RegisterJob to job handler manager
-func (rp *RequestProcess) RegisterJob(jobName string, beanJobFn BeanJobRunFunc) {
-	rp.JobManager.RegisterJob(jobName, beanJobFn)
+func (rp *RequestProcess) RegisterJob(jobName string, beanJobFn BeanJobRunFunc, mws ...BeanJobMiddleware) {
+	rp.JobManager.RegisterJob(jobName, beanJobFn, mws...)
+}
+
+// RegisterRunner to job handler manager, the runner can implement the BeanJobLifecycle.
+func (rp *RequestProcess) RegisterRunner(jobName string, runner BeanJobRunner, mws ...BeanJobMiddleware) {
+	rp.JobManager.RegisterRunner(jobName, runner, mws...)
 }
 
 // push job to queue and run it
@@ -58,10 +76,17 @@ func (rp *RequestProcess) pushJob(trigger *transport.TriggerParam) {
 		Content: "success",
 	}
 
+	metrics.Inc(metrics.TriggersTotal, metrics.Labels{"handler": triggerHandler(trigger)})
+
+	// the span will end on the task callback.
+	traceCtx, span := tracing.StartSpan(context.Background(), tracing.SpanRun, triggerSpanAttrs(trigger)...)
+
 	// push job to queue and run it.
-	err := rp.JobManager.PutJobToQueue(trigger)
+	err := rp.JobManager.putJob(traceCtx, trigger)
 	if err != nil {
-		returns.Code = http.StatusInternalServerError
+		endSpan(span, err)
+
+		returns.Code = resultCode(err)
 		returns.Content = err.Error()
 		callback := &transport.HandleCallbackParam{
 			LogId:         trigger.LogId,
@@ -73,6 +98,24 @@ func (rp *RequestProcess) pushJob(trigger *transport.TriggerParam) {
 	}
 }
 
+// goPushJob push job on new goroutine. return false on the executor is shutting down.
+func (rp *RequestProcess) goPushJob(trigger *transport.TriggerParam) bool {
+	rp.RLock()
+	defer rp.RUnlock()
+
+	if rp.closed {
+		logger.Warn("the executor is shutting down, reject run job", "jobId", trigger.JobId, "logId", trigger.LogId)
+		return false
+	}
+
+	rp.pushWg.Add(1)
+	go func() {
+		defer rp.pushWg.Done()
+		rp.pushJob(trigger)
+	}()
+	return true
+}
+
 func (rp *RequestProcess) jobRunCallback(trigger *JobRunParam, runErr error) {
 	returns := transport.ReturnT{
 		Code:    http.StatusOK,
@@ -80,9 +123,12 @@ func (rp *RequestProcess) jobRunCallback(trigger *JobRunParam, runErr error) {
 	}
 
 	if runErr != nil {
-		returns.Code = http.StatusInternalServerError
-		returns.Content = runErr.Error()
+		returns.Code = resultCode(runErr)
+		returns.Content = callbackErrMsg(runErr)
+	} else if msg := trigger.resultMsg(); msg != "" {
+		returns.Content = truncateMsg(msg)
 	}
+	endSpan(tracing.SpanFromContext(trigger.traceContext()), runErr)
 
 	callback := &transport.HandleCallbackParam{
 		LogId:         trigger.LogId,
@@ -92,6 +138,47 @@ func (rp *RequestProcess) jobRunCallback(trigger *JobRunParam, runErr error) {
 	rp.adminServer.CallbackAdmin([]*transport.HandleCallbackParam{callback})
 }
 
+// callbackErrMsg build the callback message by error. will with the stack trace on panic.
+//
+// the message is truncated to callbackMsgMaxLen, the full stack can be found in the task log.
+func callbackErrMsg(err error) string {
+	msg := err.Error()
+
+	var pe *PanicError
+	if errors.As(err, &pe) {
+		msg += "\n" + string(pe.Stack)
+	}
+	return truncateMsg(msg)
+}
+
+// truncateMsg truncate the callback message to callbackMsgMaxLen
+func truncateMsg(msg string) string {
+	if len(msg) <= callbackMsgMaxLen {
+		return msg
+	}
+
+	// avoid cut the multi-byte char
+	cut := callbackMsgMaxLen
+	for cut > 0 && !utf8.RuneStart(msg[cut]) {
+		cut--
+	}
+	return msg[:cut] + "...(truncated, see the task log)"
+}
+
+// readLog read a window of the task logs.
+// IsEnd will be true only on the task has finished and read to the end of log file.
+func (rp *RequestProcess) readLog(lq *transport.LogRequest) *logger.LogResult {
+	finished := !rp.JobManager.IsTaskRunning(lq.LogId)
+	toLine, content, eof := logger.ReadLog(lq.LogDateTim, lq.LogId, lq.FromLineNum, finished)
+
+	return &logger.LogResult{
+		FromLineNum: lq.FromLineNum,
+		ToLineNum:   toLine,
+		LogContent:  content,
+		IsEnd:       finished && eof,
+	}
+}
+
 // RequestProcess handle
 func (rp *RequestProcess) RequestProcess(ctx context.Context, r interface{}) (res []byte, err error) {
 	response := transport.XxlRpcResponse{}
@@ -122,7 +209,7 @@ func (rp *RequestProcess) RequestProcess(ctx context.Context, r interface{}) (re
 			} else {
 				if methodName != "beat" {
 					mn := rp.ReqHandler.MethodName(ctx, r)
-					logger.Debugf("received server method: %s, reqId: %s", mn, reqId)
+					logger.Debug("received server method", "method", mn, "reqId", reqId)
 
 					switch mn {
 					case MthIdleBeat:
@@ -137,9 +224,12 @@ func (rp *RequestProcess) RequestProcess(ctx context.Context, r interface{}) (re
 							returns.Content = err.Error()
 						}
 					case MthLog:
-						log, err := rp.ReqHandler.Log(ctx, r)
+						logReq, err := rp.ReqHandler.Log(ctx, r)
 						if err == nil {
-							returns.Content = log
+							returns.Content = rp.readLog(logReq)
+						} else {
+							returns.Code = http.StatusInternalServerError
+							returns.Msg = err.Error()
 						}
 					case MthKill:
 						jobId, err := rp.ReqHandler.Kill(ctx, r)
@@ -149,8 +239,9 @@ func (rp *RequestProcess) RequestProcess(ctx context.Context, r interface{}) (re
 					default: // MthRun
 						// collect and build trigger params from r, then run job
 						ta, err := rp.ReqHandler.Run(ctx, r)
-						if err == nil {
-							go rp.pushJob(ta)
+						if err == nil && !rp.goPushJob(ta) {
+							returns.Code = http.StatusInternalServerError
+							returns.Content = ErrShuttingDown.Error()
 						}
 					}
 				}
@@ -177,6 +268,36 @@ func (rp *RequestProcess) RequestProcess(ctx context.Context, r interface{}) (re
 	return bytes, nil
 }
 
+// Shutdown stop accept run requests, unregister from xxl-job admin,
+// then wait the running tasks completed and callback to admin.
+//
+// if ctx is done before the tasks completed, will kill them.
+func (rp *RequestProcess) Shutdown(ctx context.Context) error {
+	rp.Lock()
+	rp.closed = true
+	registered := rp.registered
+	rp.Unlock()
+
+	if registered {
+		rp.adminServer.StopAutoRegister()
+		rp.adminServer.UnregisterExecutor()
+	}
+
+	// wait pushing jobs put to queue
+	pushed := make(chan struct{})
+	go func() {
+		rp.pushWg.Wait()
+		close(pushed)
+	}()
+
+	select {
+	case <-pushed:
+	case <-ctx.Done():
+	}
+
+	return rp.JobManager.Shutdown(ctx)
+}
+
 // UnregisterExecutor form xxl-job admin server
 func (rp *RequestProcess) UnregisterExecutor() {
 	rp.JobManager.clearJob()
@@ -185,8 +306,15 @@ func (rp *RequestProcess) UnregisterExecutor() {
 }
 
 // RegisterExecutor to xxl-job admin server
-func (rp *RequestProcess) RegisterExecutor() {
-	rp.adminServer.RegisterExecutor()
+func (rp *RequestProcess) RegisterExecutor() error {
+	if err := rp.adminServer.RegisterExecutor(); err != nil {
+		return err
+	}
+
+	rp.Lock()
+	rp.registered = true
+	rp.Unlock()
 
 	go rp.adminServer.AutoRegisterJobGroup()
+	return nil
 }
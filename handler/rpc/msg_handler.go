@@ -2,6 +2,7 @@ package rpc
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -30,17 +31,17 @@ func NewRpcMessageHandler(transport *transport.GettyRPCClient, msgHandler func(c
 }
 
 func (h *MessageHandler) OnOpen(session getty.Session) error {
-	logger.Infof("Tcp.OnOpen - open session: %s", session.Stat())
+	logger.Info("Tcp.OnOpen - open session", "session", session.Stat())
 	h.GettyClient.AddSession(session)
 	return nil
 }
 
 func (h *MessageHandler) OnError(session getty.Session, err error) {
-	logger.Infof("Tcp.OnError - session{%s} got error{%v}, will be closed.", session.Stat(), err)
+	logger.Error("Tcp.OnError - session got error, will be closed", "session", session.Stat(), "error", err)
 }
 
 func (h *MessageHandler) OnClose(session getty.Session) {
-	logger.Infof("Tcp.OnClose - close session{%s} is closing ......", session.Stat())
+	logger.Info("Tcp.OnClose - session is closing ......", "session", session.Stat())
 
 	h.GettyClient.RemoveSession(session)
 }
@@ -48,13 +49,13 @@ func (h *MessageHandler) OnClose(session getty.Session) {
 func (h *MessageHandler) OnMessage(session getty.Session, pkg interface{}) {
 	s, ok := pkg.([]interface{})
 	if !ok {
-		logger.Errorf("Tcp.OnMessage - illegal package{%#v}", pkg)
+		logger.Error("Tcp.OnMessage - illegal package", "pkg", fmt.Sprintf("%#v", pkg))
 		return
 	}
 
 	for _, v := range s {
 		if v != nil {
-			logger.Debugf("Tcp.OnMessage - message package item{%#v}", v)
+			logger.Debug("Tcp.OnMessage - message package item", "item", fmt.Sprintf("%#v", v))
 			res, err := h.MsgHandle(context.Background(), v)
 			reply(session, res, err)
 		}
@@ -64,14 +65,14 @@ func (h *MessageHandler) OnMessage(session getty.Session, pkg interface{}) {
 func (h *MessageHandler) OnCron(sess getty.Session) {
 	active := sess.GetActive()
 	actDtime := active.Format(constants.DateTimeFormat2)
-	logger.Debugf("Tcp.OnCron - session heartbeat check, last active: %s", actDtime)
+	logger.Debug("Tcp.OnCron - session heartbeat check", "lastActive", actDtime)
 
 	if constants.CronPeriod < time.Since(active).Nanoseconds() {
-		logger.Infof(
-			"Tcp.OnCorn - session{%s} timeout{%s}(last active:%s)",
-			sess.Stat(),
-			time.Since(active).String(),
-			actDtime,
+		logger.Info(
+			"Tcp.OnCorn - session timeout",
+			"session", sess.Stat(),
+			"timeout", time.Since(active).String(),
+			"lastActive", actDtime,
 		)
 
 		sess.Close()
@@ -83,7 +84,7 @@ func (h *MessageHandler) OnCron(sess getty.Session) {
 
 func reply(sess getty.Session, resp []byte, err error) {
 	if sess.IsClosed() {
-		logger.Errorf("Tcp.OnMessage - reply error: session closed, err: %#v, resp: %s", err, string(resp))
+		logger.Error("Tcp.OnMessage - reply error: session closed", "error", err, "resp", string(resp))
 		return
 	}
 
@@ -94,6 +95,6 @@ func reply(sess getty.Session, resp []byte, err error) {
 
 	_, _, err = sess.WritePkg(pkg, writePkgTimeout)
 	if err != nil {
-		logger.Errorf("Tcp.WritePkg error: %#v, pkg: %#v", err, pkg)
+		logger.Error("Tcp.WritePkg error", "error", err, "pkg", fmt.Sprintf("%#v", pkg))
 	}
 }
@@ -56,23 +56,18 @@ func (h *RpcRequestHandler) Kill(ctx context.Context, r interface{}) (jobId int3
 	return req.Parameters[0].(int32), err
 }
 
-func (h *RpcRequestHandler) Log(ctx context.Context, r interface{}) (log *logger.LogResult, err error) {
+func (h *RpcRequestHandler) Log(ctx context.Context, r interface{}) (logReq *transport.LogRequest, err error) {
 	req := r.(*transport.XxlRpcRequest)
-	if len(req.Parameters) == 0 {
+	if len(req.Parameters) < 3 {
 		return nil, errors.New("job parameters is empty")
 	}
 
-	logId := req.Parameters[1].(int64)
-	fromLine := req.Parameters[2].(int32)
-
-	logger.Debugf("fetch job logs. logId: %d, start-line: %d, reqId: %s", logId, fromLine, req.RequestId)
-	line, content := logger.ReadLog(req.Parameters[0].(int64), req.Parameters[1].(int64), fromLine)
-
-	log = &logger.LogResult{
-		FromLineNum: fromLine,
-		ToLineNum:   line,
-		LogContent:  content,
-		IsEnd:       true,
+	logReq = &transport.LogRequest{
+		LogDateTim:  req.Parameters[0].(int64),
+		LogId:       req.Parameters[1].(int64),
+		FromLineNum: req.Parameters[2].(int32),
 	}
-	return log, err
+
+	logger.Debug("fetch job logs", "logId", logReq.LogId, "fromLine", logReq.FromLineNum, "reqId", req.RequestId)
+	return logReq, err
 }
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+	"github.com/goft-cloud/go-xxl-job-client/v2/param"
+)
+
+// PrepareCmd setting the command by ScriptOptions and glue type: the env, working dir and run as user.
+// return the resource limits of the glue type, for RunCmd()
+func PrepareCmd(cmd *exec.Cmd, cjp *param.CtxJobParam, glueType string) (ResourceLimits, error) {
+	opts := GetScriptOptions()
+
+	// inherit the process env, add job metadata ENV and the glue type ENV
+	cmd.Env = JobEnv(cjp)
+	if gt, ok := GetGlueType(glueType); ok && len(gt.Env) > 0 {
+		cmd.Env = appendEnv(cmd.Env, gt.Env)
+	}
+
+	dir, err := opts.TaskWorkDir(cjp)
+	if err != nil {
+		return ResourceLimits{}, err
+	}
+	cmd.Dir = dir
+
+	if opts.Credential != nil {
+		if err := setCredential(cmd, opts.Credential); err != nil {
+			return ResourceLimits{}, err
+		}
+		logger.LogJobf(newJobLogCtx(cjp), "run the task as uid=%d gid=%d", opts.Credential.Uid, opts.Credential.Gid)
+	}
+	return opts.LimitsFor(glueType), nil
+}
+
+// OpenCmdOutput redirect the command stdout and stderr to the task log by pipes.
+// each output line will with the time and stream prefix. the output should be closed after the command exited.
+func OpenCmdOutput(cmd *exec.Cmd, logfile string) (*logger.TaskOutput, error) {
+	out, err := logger.NewTaskOutput(logfile, GetScriptOptions().MaxLogSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if cmd.Stdout, err = out.Pipe(logger.StreamStdout); err == nil {
+		cmd.Stderr, err = out.Pipe(logger.StreamStderr)
+	}
+
+	if err != nil {
+		_ = out.Close()
+		return nil, err
+	}
+	return out, nil
+}
+
+// RunCmd run the command in a new process group and wait for it to exit.
+//
+// the limits will be applied before exec the command, and the error will contain the reason on exceeded a limit.
+// the per task work dir(with {logId}) will be removed after the process exited.
+//
+// on the ctx done(job killed or timeout), will send SIGTERM to the whole process group,
+// and send SIGKILL if the processes are not exited after the ScriptOptions.KillGracePeriod.
+// the ctx should be with the job param, for write the task log.
+func RunCmd(ctx context.Context, cmd *exec.Cmd, limits ResourceLimits) error {
+	if cjp, _ := GetCtxJobParam(ctx); cjp != nil {
+		defer func() {
+			if err := GetScriptOptions().removeTaskWorkDir(cjp); err != nil {
+				logger.Error("remove the task work dir failed", "logId", cjp.LogID, "error", err)
+			}
+		}()
+	}
+
+	if !limits.IsEmpty() {
+		if err := limitCmd(cmd, limits); err != nil {
+			return fmt.Errorf("apply the resource limits(%s) failed: %w", limits, err)
+		}
+	}
+
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start the process failed: %w", err)
+	}
+
+	if !limits.IsEmpty() {
+		logger.LogJobf(ctx, "the process(pid: %d) resource limits: %s", cmd.Process.Pid, limits)
+	}
+
+	err := waitCmd(ctx, cmd)
+	if reason := limitExitReason(err, limits); reason != "" {
+		logger.LogJobf(ctx, "the process(pid: %d) is stopped, %s", cmd.Process.Pid, reason)
+		return fmt.Errorf("%s: %w", reason, err)
+	}
+	return err
+}
+
+// waitCmd wait the process exit, will stop the process group on ctx done.
+func waitCmd(ctx context.Context, cmd *exec.Cmd) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+
+	pid := cmd.Process.Pid
+	cause := "job killed"
+	if ctx.Err() == context.DeadlineExceeded {
+		cause = "job timeout"
+	}
+
+	logger.LogJobf(ctx, "stop the process group(pgid: %d) by SIGTERM, cause: %s", pid, cause)
+	if err := terminateProcess(cmd); err != nil {
+		logger.Error("send SIGTERM to the process group failed", "pgid", pid, "error", err.Error())
+	}
+
+	grace := GetScriptOptions().killGracePeriod()
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+	}
+
+	logger.LogJobf(ctx, "the process group(pgid: %d) is not exited after %s, kill it by SIGKILL", pid, grace)
+	if err := killProcess(cmd); err != nil {
+		logger.Error("send SIGKILL to the process group failed", "pgid", pid, "error", err.Error())
+	}
+	return <-done
+}
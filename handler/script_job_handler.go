@@ -2,7 +2,6 @@ package handler
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -11,28 +10,13 @@ import (
 
 	"github.com/goft-cloud/go-xxl-job-client/v2/constants"
 	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+	"github.com/goft-cloud/go-xxl-job-client/v2/param"
 	"github.com/goft-cloud/go-xxl-job-client/v2/transport"
 )
 
-var scriptMap = map[string]string{
-	"GLUE_SHELL":      ".sh",
-	"GLUE_PYTHON":     ".py",
-	"GLUE_PHP":        ".php",
-	"GLUE_NODEJS":     ".js",
-	"GLUE_POWERSHELL": ".ps1",
-}
-
-var scriptBin = map[string]string{
-	"GLUE_SHELL":      constants.ShellBash,
-	"GLUE_PYTHON":     "python",
-	"GLUE_PHP":        "php",
-	"GLUE_NODEJS":     "node",
-	"GLUE_POWERSHELL": "powershell",
-}
-
 // SetShellBin custom set shell bin
 func SetShellBin(binName string) {
-	scriptBin["GLUE_SHELL"] = binName
+	_ = SetGlueBin("GLUE_SHELL", binName)
 }
 
 // ScriptHandler struct
@@ -42,44 +26,30 @@ type ScriptHandler struct {
 
 // ParseJob info
 func (s *ScriptHandler) ParseJob(trigger *transport.TriggerParam) (jrp *JobRunParam, err error) {
-	suffix, ok := scriptMap[trigger.GlueType]
+	gt, ok := GetGlueType(trigger.GlueType)
 	if !ok {
 		cjp := NewCtxJobParamByTpp(trigger)
 		ctx := context.WithValue(context.Background(), constants.CtxParamKey, cjp)
 
-		msg := "暂不支持" + strings.ToLower(trigger.GlueType[constants.GluePrefixLen:]) + "脚本"
+		msg := "暂不支持" + strings.ToLower(strings.TrimPrefix(trigger.GlueType, constants.GluePrefix)) + "脚本"
 		logger.LogJobf(ctx, "job#%d parse error: %s", trigger.JobId, msg)
-		return jrp, errors.New(msg)
+		return jrp, fmt.Errorf("%w: %s(%s)", ErrUnsupportedGlueType, trigger.GlueType, msg)
 	}
 
-	// path := fmt.Sprintf("%s_%d_%d%s", constants.GlueSourcePath, trigger.JobId, trigger.GlueUpdatetime, suffix)
-	path := fmt.Sprintf("%s/job%d_%d%s", logger.GlueSourcePath(), trigger.JobId, trigger.GlueUpdatetime, suffix)
-	_, err = os.Stat(path)
-	if err != nil && os.IsNotExist(err) {
-		s.Lock()
-		defer s.Unlock()
-		file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0750)
-		if err != nil && os.IsNotExist(err) {
-			// err = os.MkdirAll(constants.GlueSourcePath, os.ModePerm)
-			err = os.MkdirAll(logger.GlueSourcePath(), os.ModePerm)
-			if err == nil {
-				file, err = os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0750)
-				if err != nil {
-					return jrp, err
-				}
-			}
-		}
+	// the script is referenced by the task until it completed, the superseded version will be kept before that.
+	path, release, err := writeGlueScript(trigger.JobId, trigger.GlueUpdatetime, gt.Suffix, trigger.GlueSource)
+	if err != nil {
+		ctx := newJobLogCtx(NewCtxJobParamByTpp(trigger))
+		logger.LogJobf(ctx, "job#%d write glue script error: %s", trigger.JobId, err.Error())
+		return nil, err
+	}
 
-		if file != nil {
-			defer file.Close()
-			res, err := file.Write([]byte(trigger.GlueSource))
-			if err != nil {
-				return jrp, err
-			}
-			if res <= 0 {
-				return jrp, errors.New("write script file failed")
-			}
-		}
+	// check the params can be parsed to cmd args
+	if _, err = param.ParseLines(trigger.ExecutorParams); err != nil {
+		release()
+		ctx := newJobLogCtx(NewCtxJobParamByTpp(trigger))
+		logger.LogJobf(ctx, "job#%d parse params error: %s", trigger.JobId, err.Error())
+		return nil, err
 	}
 
 	inputParam := make(map[string]string)
@@ -97,12 +67,8 @@ func (s *ScriptHandler) ParseJob(trigger *transport.TriggerParam) (jrp *JobRunPa
 	jrp = NewJobRunParam(trigger).WithOptionFn(func(jrp *JobRunParam) {
 		jrp.JobTag = path
 		jrp.InputParam = inputParam
+		jrp.release = release
 	})
-
-	if trigger.BroadcastTotal > 0 {
-		jrp.ShardIdx = trigger.BroadcastIndex
-		jrp.ShardTotal = trigger.BroadcastTotal
-	}
 	return jrp, nil
 }
 
@@ -112,62 +78,73 @@ func (s *ScriptHandler) Execute(jobId int32, glueType string, runParam *JobRunPa
 	cjp := NewCtxJobParamByJrp(jobId, runParam)
 
 	// dump.P(cjp)
-	logger.Debugf("job#%d - exec script task#%d, type: %s, params: %v", jobId, logId, glueType, cjp.String())
+	logger.Debug("exec script task", "jobId", jobId, "logId", logId, "glueType", glueType, "params", cjp.String())
 	ctx := context.WithValue(context.Background(), constants.CtxParamKey, cjp)
 
-	binName := scriptBin[glueType]
+	gt, ok := GetGlueType(glueType)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnsupportedGlueType, glueType)
+	}
+
+	// the old version glue script is removed on the glue source updated, and no task references it
+	if _, err := os.Stat(runParam.JobTag); os.IsNotExist(err) {
+		logger.LogJobf(ctx, "run task#%d failed, the glue script is superseded by a new version", logId)
+		return fmt.Errorf("the glue script %s is not exists, may be superseded by a new version", runParam.JobTag)
+	}
+
+	binName := gt.Bin
 	logfile := logger.LogfilePath(logId)
 
-	cancelCtx, canFun := context.WithCancel(context.Background())
+	cancelCtx, canFun := runParam.newRunContext(runParam.traceContext())
 	defer canFun()
-	runParam.CurrentCancelFunc = canFun
+	runParam.setCancel(canFun)
 
 	logger.LogJobf(ctx, "task#%d %s script start run!", logId, binName)
 
 	args := runParam.BuildCmdArgs()
-	cmd := exec.CommandContext(cancelCtx, binName, args...)
-
-	// add shard ENV
-	// if runParam.ShardTotal > 0 {
-	//  TIP: if set env, will not load default env data.
-	// 	cmd.Env = []string{
-	// 		constants.EnvXxlShardIdx + "=" + strutil.MustString(runParam.ShardIdx),
-	// 		constants.EnvXxlShardTotal + "=" + strutil.MustString(runParam.ShardTotal),
-	// 	}
-	// }
+	cmd := exec.Command(binName, gt.cmdArgs(args)...)
 
-	fh, err := logger.OpenLogFile(logfile)
+	// set env, work dir and run as user. see ScriptOptions
+	limits, err := PrepareCmd(cmd, cjp, glueType)
 	if err != nil {
+		logger.LogJobf(ctx, "run task#%d script failed, error: %s", logId, err.Error())
 		return err
 	}
 
-	// use file receive output
-	cmd.Stderr = fh
-	cmd.Stdout = fh
+	// the glue script is owned by the executor user, let the run as user can read and exec it.
+	if cred := GetScriptOptions().Credential; cred != nil {
+		if err := shareGlueScript(runParam.JobTag, cred); err != nil {
+			logger.LogJobf(ctx, "run task#%d script failed, share the glue script to gid %d: %s", logId, cred.Gid, err.Error())
+			return err
+		}
+	}
 
-	// see: https://stackoverflow.com/questions/48926982/write-stdout-stream-to-file
-	// stdout, err := cmd.StdoutPipe()
-	// if err != nil {
-	// 	return err
-	// }
-	// go io.Copy(fh, stdout)
+	// read the stdout and stderr by pipes, write to log file with prefix
+	out, err := OpenCmdOutput(cmd, logfile)
+	if err != nil {
+		return err
+	}
 
-	logger.Debugf("job#%d - will run task#%d script: '%s', logfile: %s", jobId, logId, cmd.String(), logfile)
-	if err := cmd.Run(); err != nil {
-		_ = fh.Close() // close log file.
+	logger.Debug("will run script task", "jobId", jobId, "logId", logId, "cmd", cmd.String(), "logfile", logfile)
+	// the run ctx with job param, for write the stop reason to task log
+	runCtx := context.WithValue(cancelCtx, constants.CtxParamKey, cjp)
+	if err := RunCmd(runCtx, cmd, limits); err != nil {
+		_ = out.Close() // close log file.
 
-		errMsg := err.Error()
-		if ee, ok := err.(*exec.ExitError); ok {
-			errMsg = ee.String() + "; " + string(ee.Stderr)
+		if cancelCtx.Err() == context.DeadlineExceeded {
+			logger.Error("run script task timeout", "jobId", jobId, "logId", logId, "timeout", runParam.Timeout)
+			logger.LogJobf(ctx, "run task#%d script failed, job timeout, timeout: %s", logId, runParam.Timeout)
+			return runParam.timeoutError()
 		}
 
-		logger.Errorf("job#%d - run task#%d script command error: %s", jobId, logId, errMsg)
-		logger.LogJobf(ctx, "run task#%d script failed, error: %s", logId, errMsg)
+		// the stderr output is written to the task log
+		logger.Error("run script task command failed", "jobId", jobId, "logId", logId, "error", err)
+		logger.LogJobf(ctx, "run task#%d script failed, error: %s", logId, err.Error())
 		return err
 	}
 
-	err = fh.Close() // close log file.
-	logger.Debugf("job#%d - run task#%d script success", jobId, logId)
+	err = out.Close() // close log file.
+	logger.Debug("run script task success", "jobId", jobId, "logId", logId)
 	logger.LogJobf(ctx, "task#%d script run success!", logId)
 	return err
 }
@@ -0,0 +1,253 @@
+package handler_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/handler"
+	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+	"github.com/goft-cloud/go-xxl-job-client/v2/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScriptHandler_Execute_env(t *testing.T) {
+	handler.ConfigScript(func(opts *handler.ScriptOptions) {
+		opts.Env = map[string]string{"MY_ENV": "my-value"}
+		opts.DisableShardArgs = true
+	})
+	defer handler.ConfigScript(func(opts *handler.ScriptOptions) {
+		*opts = handler.ScriptOptions{}
+	})
+
+	sh := &handler.ScriptHandler{}
+	jrp, err := sh.ParseJob(&transport.TriggerParam{
+		JobId:          6,
+		LogId:          61,
+		GlueType:       "GLUE_SHELL",
+		GlueSource:     "echo \"args: $*\"\necho \"env: $XXL_JOB_ID $XXL_LOG_ID $XXL_SHARD_IDX/$XXL_SHARD_TOTAL $XXL_JOB_PARAM $MY_ENV\"\n",
+		GlueUpdatetime: 1,
+		ExecutorParams: "arg0",
+		BroadcastIndex: 1,
+		BroadcastTotal: 2,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{jrp.JobTag, "arg0"}, jrp.BuildCmdArgs())
+
+	assert.NoError(t, sh.Execute(6, "GLUE_SHELL", jrp))
+
+	bs, err := ioutil.ReadFile(logger.LogfilePath(61))
+	assert.NoError(t, err)
+	assert.Contains(t, string(bs), "args: arg0\n")
+	assert.Contains(t, string(bs), "env: 6 61 1/2 arg0 my-value\n")
+}
+
+func TestScriptHandler_Execute_killGroup(t *testing.T) {
+	handler.ConfigScript(func(opts *handler.ScriptOptions) {
+		opts.KillGracePeriod = 300 * time.Millisecond
+	})
+	defer handler.ConfigScript(func(opts *handler.ScriptOptions) {
+		*opts = handler.ScriptOptions{}
+	})
+
+	sh := &handler.ScriptHandler{}
+	jrp, err := sh.ParseJob(&transport.TriggerParam{
+		JobId:          7,
+		LogId:          71,
+		GlueType:       "GLUE_SHELL",
+		GlueSource:     "trap '' TERM\nsleep 30 &\nwait\n",
+		GlueUpdatetime: 1,
+	})
+	assert.NoError(t, err)
+	jrp.Timeout = 500 * time.Millisecond
+
+	start := time.Now()
+	err = sh.Execute(7, "GLUE_SHELL", jrp)
+	assert.ErrorIs(t, err, handler.ErrJobTimeout)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	bs, err := ioutil.ReadFile(logger.LogfilePath(71))
+	assert.NoError(t, err)
+	assert.Contains(t, string(bs), "by SIGTERM, cause: job timeout")
+	assert.Contains(t, string(bs), "kill it by SIGKILL")
+}
+
+func TestScriptHandler_Execute_limits(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the resource limits only supported on linux")
+	}
+
+	workDir, err := ioutil.TempDir("", "xxl-job-workdir")
+	assert.NoError(t, err)
+	defer os.RemoveAll(workDir)
+
+	handler.ConfigScript(func(opts *handler.ScriptOptions) {
+		opts.WorkDir = filepath.Join(workDir, "job{jobId}")
+		opts.Limits = handler.ResourceLimits{OpenFiles: 64, Memory: 1 << 30}
+		opts.GlueLimits = map[string]handler.ResourceLimits{
+			"GLUE_SHELL": {CPUTime: time.Second},
+		}
+	})
+	defer handler.ConfigScript(func(opts *handler.ScriptOptions) {
+		*opts = handler.ScriptOptions{}
+	})
+
+	sh := &handler.ScriptHandler{}
+	jrp, err := sh.ParseJob(&transport.TriggerParam{
+		JobId:          8,
+		LogId:          81,
+		GlueType:       "GLUE_SHELL",
+		GlueSource:     "echo \"pwd: $(pwd)\"\necho \"nofile: $(ulimit -n)\"\necho \"cpu: $(ulimit -t)\"\necho \"as: $(ulimit -v)\"\nwhile :; do :; done\n",
+		GlueUpdatetime: 1,
+	})
+	assert.NoError(t, err)
+
+	err = sh.Execute(8, "GLUE_SHELL", jrp)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "exceeded the CPU time limit 1s")
+	}
+
+	bs, err := ioutil.ReadFile(logger.LogfilePath(81))
+	assert.NoError(t, err)
+	assert.Contains(t, string(bs), "pwd: "+filepath.Join(workDir, "job8")+"\n")
+	// the limits are applied before exec the script
+	assert.Contains(t, string(bs), "nofile: 64\n")
+	assert.Contains(t, string(bs), "cpu: 1\n")
+	assert.Contains(t, string(bs), "as: 1048576\n")
+	assert.Contains(t, string(bs), "resource limits: cpu=1s, memory=1073741824, nofile=64")
+}
+
+func TestScriptHandler_Execute_workDir(t *testing.T) {
+	workDir, err := ioutil.TempDir("", "xxl-job-workdir")
+	assert.NoError(t, err)
+	defer os.RemoveAll(workDir)
+
+	handler.ConfigScript(func(opts *handler.ScriptOptions) {
+		opts.WorkDir = filepath.Join(workDir, "job{jobId}", "{logId}", "work")
+	})
+	defer handler.ConfigScript(func(opts *handler.ScriptOptions) {
+		*opts = handler.ScriptOptions{}
+	})
+
+	_ = os.Remove(logger.LogfilePath(201))
+	sh := &handler.ScriptHandler{}
+	jrp, err := sh.ParseJob(&transport.TriggerParam{
+		JobId:          20,
+		LogId:          201,
+		GlueType:       "GLUE_SHELL",
+		GlueSource:     "echo \"pwd: $(pwd)\"\n",
+		GlueUpdatetime: 1,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, sh.Execute(20, "GLUE_SHELL", jrp))
+
+	bs, err := ioutil.ReadFile(logger.LogfilePath(201))
+	assert.NoError(t, err)
+	assert.Contains(t, string(bs), "pwd: "+filepath.Join(workDir, "job20", "201", "work")+"\n")
+
+	// the per task dir is removed, the job dir is kept
+	_, err = os.Stat(filepath.Join(workDir, "job20", "201"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(workDir, "job20"))
+	assert.NoError(t, err)
+}
+
+func TestScriptHandler_Execute_output(t *testing.T) {
+	_ = os.Remove(logger.LogfilePath(121))
+	sh := &handler.ScriptHandler{}
+	jrp, err := sh.ParseJob(&transport.TriggerParam{
+		JobId:          12,
+		LogId:          121,
+		GlueType:       "GLUE_SHELL",
+		GlueSource:     "echo out-line1\necho err-line1 >&2\necho out-line2\necho err-line2 >&2\n",
+		GlueUpdatetime: 1,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, sh.Execute(12, "GLUE_SHELL", jrp))
+
+	bs, err := ioutil.ReadFile(logger.LogfilePath(121))
+	assert.NoError(t, err)
+
+	// the streams are read concurrently, check the lines of each stream separately.
+	str := string(bs)
+	assert.Equal(t, []string{"out-line1", "out-line2"}, streamLines(str, logger.StreamStdout))
+	assert.Equal(t, []string{"err-line1", "err-line2"}, streamLines(str, logger.StreamStderr))
+}
+
+func TestScriptHandler_Execute_maxLogSize(t *testing.T) {
+	handler.ConfigScript(func(opts *handler.ScriptOptions) {
+		opts.MaxLogSize = 4096
+	})
+	defer handler.ConfigScript(func(opts *handler.ScriptOptions) {
+		*opts = handler.ScriptOptions{}
+	})
+
+	_ = os.Remove(logger.LogfilePath(122))
+	sh := &handler.ScriptHandler{}
+	jrp, err := sh.ParseJob(&transport.TriggerParam{
+		JobId:          12,
+		LogId:          122,
+		GlueType:       "GLUE_SHELL",
+		GlueSource:     "for i in $(seq 1 500); do echo \"line $i\"; done\n",
+		GlueUpdatetime: 2,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, sh.Execute(12, "GLUE_SHELL", jrp))
+
+	bs, err := ioutil.ReadFile(logger.LogfilePath(122))
+	assert.NoError(t, err)
+
+	str := string(bs)
+	lines := streamLines(str, logger.StreamStdout)
+	assert.NotEmpty(t, lines)
+	assert.Equal(t, "line 1", lines[0])
+	assert.Contains(t, str, "the output exceeded the max log size 4096 bytes")
+	assert.NotContains(t, str, "line 500\n")
+}
+
+// streamLines collect the output lines of the stream from log content
+func streamLines(str, stream string) []string {
+	var lines []string
+	tag := " [" + stream + "] "
+	for _, line := range strings.Split(str, "\n") {
+		if i := strings.Index(line, tag); i >= 0 {
+			lines = append(lines, line[i+len(tag):])
+		}
+	}
+	return lines
+}
+
+func TestScriptHandler_Execute_credential(t *testing.T) {
+	if runtime.GOOS != "linux" || os.Getuid() != 0 {
+		t.Skip("run as user requires root on linux")
+	}
+
+	// the run as user need access the glue scripts dir
+	assert.NoError(t, os.Chmod(logger.LogBasePath(), 0755))
+	handler.ConfigScript(func(opts *handler.ScriptOptions) {
+		opts.Credential = &handler.Credential{Uid: 65534, Gid: 65534}
+	})
+	defer handler.ConfigScript(func(opts *handler.ScriptOptions) {
+		*opts = handler.ScriptOptions{}
+	})
+
+	_ = os.Remove(logger.LogfilePath(131))
+	sh := &handler.ScriptHandler{}
+	jrp, err := sh.ParseJob(&transport.TriggerParam{
+		JobId:          13,
+		LogId:          131,
+		GlueType:       "GLUE_SHELL",
+		GlueSource:     "echo \"uid: $(id -u)\"\n",
+		GlueUpdatetime: 1,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, sh.Execute(13, "GLUE_SHELL", jrp))
+
+	bs, err := ioutil.ReadFile(logger.LogfilePath(131))
+	assert.NoError(t, err)
+	assert.Contains(t, string(bs), "uid: 65534\n")
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/param"
+)
+
+// GlueTypeBean the glue type of bean jobs, use for config the limits of the cmd job. see beanjob.NewCmdHandler()
+const GlueTypeBean = "BEAN"
+
+// ResourceLimits for the script process. the zero value is not limit.
+//
+// the limits are applied by setrlimit in a re-exec helper(the executor itself) before exec the command,
+// only supported on linux. the executor binary must be executable by the Credential user if it is set.
+type ResourceLimits struct {
+	// CPUTime max CPU time, RLIMIT_CPU. the process will receive SIGXCPU on exceeded
+	CPUTime time.Duration
+	// Memory max virtual memory(address space) bytes, RLIMIT_AS
+	Memory uint64
+	// OpenFiles max open files, RLIMIT_NOFILE
+	OpenFiles uint64
+}
+
+// IsEmpty check has no limits
+func (l ResourceLimits) IsEmpty() bool {
+	return l.CPUTime <= 0 && l.Memory == 0 && l.OpenFiles == 0
+}
+
+// merge the non-zero fields of o to l
+func (l ResourceLimits) merge(o ResourceLimits) ResourceLimits {
+	if o.CPUTime > 0 {
+		l.CPUTime = o.CPUTime
+	}
+	if o.Memory > 0 {
+		l.Memory = o.Memory
+	}
+	if o.OpenFiles > 0 {
+		l.OpenFiles = o.OpenFiles
+	}
+	return l
+}
+
+// String of the limits
+func (l ResourceLimits) String() string {
+	var ss []string
+	if l.CPUTime > 0 {
+		ss = append(ss, "cpu="+l.CPUTime.String())
+	}
+	if l.Memory > 0 {
+		ss = append(ss, "memory="+strconv.FormatUint(l.Memory, 10))
+	}
+	if l.OpenFiles > 0 {
+		ss = append(ss, "nofile="+strconv.FormatUint(l.OpenFiles, 10))
+	}
+	return strings.Join(ss, ", ")
+}
+
+// Credential the user and group for run the script process
+type Credential struct {
+	Uid uint32
+	Gid uint32
+}
+
+// LimitsFor get the resource limits of the glue type. the glue type limits will override the executor limits.
+func (o ScriptOptions) LimitsFor(glueType string) ResourceLimits {
+	return o.Limits.merge(o.GlueLimits[glueType])
+}
+
+// TaskWorkDir build the working dir for run the task, will create it if not exists.
+// return empty string on the WorkDir is not set.
+func (o ScriptOptions) TaskWorkDir(cjp *param.CtxJobParam) (string, error) {
+	if o.WorkDir == "" {
+		return "", nil
+	}
+
+	dir := replaceDirVars(o.WorkDir, cjp)
+
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return "", fmt.Errorf("create the task work dir %q failed: %w", dir, err)
+	}
+
+	if o.Credential != nil {
+		if err := os.Chown(dir, int(o.Credential.Uid), int(o.Credential.Gid)); err != nil {
+			return "", fmt.Errorf("chown the task work dir %q failed: %w", dir, err)
+		}
+	}
+	return dir, nil
+}
+
+// removeTaskWorkDir remove the per task part of the work dir after the task completed.
+// it is the path to the first element contains {logId}, the dir shared by tasks is kept.
+func (o ScriptOptions) removeTaskWorkDir(cjp *param.CtxJobParam) error {
+	idx := strings.Index(o.WorkDir, "{logId}")
+	if idx < 0 {
+		return nil
+	}
+
+	tpl := o.WorkDir
+	if end := strings.IndexByte(tpl[idx:], '/'); end >= 0 {
+		tpl = tpl[:idx+end]
+	}
+	return os.RemoveAll(replaceDirVars(tpl, cjp))
+}
+
+func replaceDirVars(dir string, cjp *param.CtxJobParam) string {
+	return strings.NewReplacer(
+		"{jobId}", strconv.Itoa(int(cjp.JobID)),
+		"{logId}", strconv.FormatInt(cjp.LogID, 10),
+	).Replace(dir)
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/constants"
+	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+	"github.com/goft-cloud/go-xxl-job-client/v2/param"
+)
+
+// ScriptOptions the options for run script and cmd jobs
+type ScriptOptions struct {
+	// Env custom env vars for all script jobs
+	Env map[string]string
+	// DisableShardArgs not append the shard index and total to the script args.
+	// them can be got from env XXL_SHARD_IDX, XXL_SHARD_TOTAL
+	DisableShardArgs bool
+	// KillGracePeriod wait time after send SIGTERM to the process group on job stop,
+	// then will send SIGKILL. default is DefaultKillGracePeriod
+	KillGracePeriod time.Duration
+	// Limits the resource limits for all script and cmd jobs
+	Limits ResourceLimits
+	// GlueLimits the resource limits by glue type, will override the Limits. eg: "GLUE_PYTHON"
+	GlueLimits map[string]ResourceLimits
+	// WorkDir the working dir for run the task. support vars: {jobId}, {logId}
+	//
+	// eg: "/tmp/xxl-job/job{jobId}/{logId}", default is the current dir.
+	// the per task dir(the path to {logId}) is removed after the task completed.
+	WorkDir string
+	// Credential run the task as the user and group. only supported on unix
+	Credential *Credential
+	// MaxLogSize max bytes of the task log file, the exceeded output will be discarded. <= 0 is not limit.
+	MaxLogSize int64
+}
+
+// DefaultKillGracePeriod default grace period for stop the script process
+const DefaultKillGracePeriod = 5 * time.Second
+
+func (o ScriptOptions) killGracePeriod() time.Duration {
+	if o.KillGracePeriod > 0 {
+		return o.KillGracePeriod
+	}
+	return DefaultKillGracePeriod
+}
+
+var (
+	scriptOptMu sync.RWMutex
+	scriptOpts  = ScriptOptions{}
+)
+
+// ConfigScript config the script options
+func ConfigScript(fn func(opts *ScriptOptions)) {
+	scriptOptMu.Lock()
+	defer scriptOptMu.Unlock()
+
+	fn(&scriptOpts)
+}
+
+// GetScriptOptions get a copy of the script options
+func GetScriptOptions() ScriptOptions {
+	scriptOptMu.RLock()
+	defer scriptOptMu.RUnlock()
+
+	opts := scriptOpts
+	opts.Env = make(map[string]string, len(scriptOpts.Env))
+	for k, v := range scriptOpts.Env {
+		opts.Env[k] = v
+	}
+
+	opts.GlueLimits = make(map[string]ResourceLimits, len(scriptOpts.GlueLimits))
+	for k, v := range scriptOpts.GlueLimits {
+		opts.GlueLimits[k] = v
+	}
+
+	if scriptOpts.Credential != nil {
+		c := *scriptOpts.Credential
+		opts.Credential = &c
+	}
+	return opts
+}
+
+// JobEnv build the env vars for run script or cmd job.
+//
+// include the process env, the custom env by ScriptOptions and the job metadata:
+// XXL_JOB_ID, XXL_LOG_ID, XXL_SHARD_IDX, XXL_SHARD_TOTAL, XXL_JOB_PARAM, XXL_LOG_FILE
+func JobEnv(cjp *param.CtxJobParam) []string {
+	opts := GetScriptOptions()
+	env := appendEnv(os.Environ(), opts.Env)
+
+	return append(env,
+		constants.EnvXxlJobId+"="+strconv.Itoa(int(cjp.JobID)),
+		constants.EnvXxlLogId+"="+strconv.FormatInt(cjp.LogID, 10),
+		constants.EnvXxlShardIdx+"="+strconv.Itoa(int(cjp.ShardIndex)),
+		constants.EnvXxlShardTotal+"="+strconv.Itoa(int(cjp.ShardTotal)),
+		constants.EnvXxlJobParam+"="+cjp.InputParam,
+		constants.EnvXxlLogFile+"="+logger.LogfilePath(cjp.LogID),
+	)
+}
+
+// appendEnv append the env vars, sorted by name.
+func appendEnv(env []string, mp map[string]string) []string {
+	keys := make([]string, 0, len(mp))
+	for k := range mp {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		env = append(env, k+"="+mp[k])
+	}
+	return env
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/constants"
+	"github.com/goft-cloud/go-xxl-job-client/v2/tracing"
+	"github.com/goft-cloud/go-xxl-job-client/v2/transport"
+)
+
+// triggerSpanAttrs build the run request span attributes
+func triggerSpanAttrs(ttp *transport.TriggerParam) []tracing.Attr {
+	return []tracing.Attr{
+		tracing.KV(tracing.AttrJobId, ttp.JobId),
+		tracing.KV(tracing.AttrLogId, ttp.LogId),
+		tracing.KV(tracing.AttrHandler, triggerHandler(ttp)),
+		tracing.KV(tracing.AttrShardIndex, ttp.BroadcastIndex),
+		tracing.KV(tracing.AttrShardTotal, ttp.BroadcastTotal),
+	}
+}
+
+// taskSpanAttrs build the task execute span attributes
+func taskSpanAttrs(jobId int32, jrp *JobRunParam) []tracing.Attr {
+	return []tracing.Attr{
+		tracing.KV(tracing.AttrJobId, jobId),
+		tracing.KV(tracing.AttrLogId, jrp.LogId),
+		tracing.KV(tracing.AttrHandler, jrp.JobName),
+		tracing.KV(tracing.AttrShardIndex, jrp.ShardIdx),
+		tracing.KV(tracing.AttrShardTotal, jrp.ShardTotal),
+	}
+}
+
+// endSpan set the result and end the span
+func endSpan(span tracing.Span, err error) {
+	span.SetAttributes(tracing.KV(tracing.AttrResult, resultCode(err)))
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// resultCode get the task handle result code by error
+func resultCode(err error) int32 {
+	if err == nil {
+		return constants.HandleCodeSuccess
+	}
+	var he *HandleError
+	if errors.As(err, &he) {
+		return he.Code
+	}
+	if errors.Is(err, ErrJobTimeout) {
+		return constants.HandleCodeTimeout
+	}
+	if errors.Is(err, ErrUnsupportedGlueType) {
+		return constants.HandleCodeUnsupported
+	}
+	return constants.HandleCodeFail
+}
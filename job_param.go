@@ -5,6 +5,7 @@ import (
 	"errors"
 
 	"github.com/goft-cloud/go-xxl-job-client/v2/constants"
+	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
 	"github.com/goft-cloud/go-xxl-job-client/v2/param"
 )
 
@@ -41,6 +42,22 @@ func GetParamObj(ctx context.Context) (*param.CtxJobParam, error) {
 	return nil, errors.New("job param not exists")
 }
 
+// BindParams bind the job input params to struct ptr. see param.CtxJobParam.Bind()
+//
+// on bind or validate failed, the error will be written to the task log.
+// the handler should return it, then the task will be failed.
+func BindParams(ctx context.Context, ptr interface{}) error {
+	obj, err := GetParamObj(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = obj.Bind(ptr); err != nil {
+		logger.LogJobf(ctx, "bind job params failed! %s", err.Error())
+	}
+	return err
+}
+
 // GetSharding info
 func GetSharding(ctx context.Context) (shardIdx, shardTotal int32) {
 	if obj, err := GetParamObj(ctx); err == nil {
@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// GlueFile the cached glue script file info
+type GlueFile struct {
+	// Name of file. eg: job12_1641052800000.sh
+	Name string
+	// Path full path of file
+	Path       string
+	JobId      int32
+	UpdateTime int64
+	Size       int64
+	ModTime    time.Time
+}
+
+// ListGlueFiles list the cached glue script files, sorted by jobId and updateTime.
+func ListGlueFiles() ([]GlueFile, error) {
+	files, err := ioutil.ReadDir(GlueSourcePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var gfs []GlueFile
+	for _, fi := range files {
+		ss := gluefileRegex.FindStringSubmatch(fi.Name())
+		if fi.IsDir() || len(ss) == 0 {
+			continue
+		}
+
+		jobId, _ := strconv.ParseInt(ss[1], 10, 32)
+		updateTime, _ := strconv.ParseInt(ss[2], 10, 64)
+		gfs = append(gfs, GlueFile{
+			Name:       fi.Name(),
+			Path:       filepath.Join(GlueSourcePath(), fi.Name()),
+			JobId:      int32(jobId),
+			UpdateTime: updateTime,
+			Size:       fi.Size(),
+			ModTime:    fi.ModTime(),
+		})
+	}
+
+	sort.Slice(gfs, func(i, j int) bool {
+		if gfs[i].JobId != gfs[j].JobId {
+			return gfs[i].JobId < gfs[j].JobId
+		}
+		return gfs[i].UpdateTime < gfs[j].UpdateTime
+	})
+	return gfs, nil
+}
+
+// ListGlueTempFiles list the temp files of writing glue scripts. eg: .job12_123456.tmp
+func ListGlueTempFiles() ([]string, error) {
+	return filepath.Glob(filepath.Join(GlueSourcePath(), ".job*.tmp"))
+}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"os"
 	"time"
+	"unicode/utf8"
 
 	"github.com/goft-cloud/go-xxl-job-client/v2/constants"
 	"github.com/goft-cloud/go-xxl-job-client/v2/param"
@@ -17,6 +18,13 @@ import (
 
 var logBasPath string
 
+const (
+	// ReadLogMaxLines max lines for read the job log once
+	ReadLogMaxLines = 1000
+	// ReadLogMaxBytes max bytes for read the job log once, must less than the getty max message length.
+	ReadLogMaxBytes = 64 * 1024
+)
+
 // LogResult struct
 type LogResult struct {
 	FromLineNum int32  `json:"fromLineNum"`
@@ -69,7 +77,7 @@ func LogfileName(logId int64) string {
 
 // InitLogPath dir
 func InitLogPath() error {
-	Infof("job logs base path dir: %s", logBasPath)
+	Info("job logs base path dir", "path", logBasPath)
 
 	_, err := os.Stat(LogBasePath())
 	if err != nil && os.IsNotExist(err) {
@@ -88,7 +96,7 @@ func LogJobf(ctx context.Context, tpl string, args ...interface{}) {
 func LogJob(ctx context.Context, args ...interface{}) {
 	val := ctx.Value(constants.CtxParamKey)
 	if val == nil {
-		Errorf("the job task ctx %s not exists", constants.CtxParamKey)
+		Error("the job task ctx param not exists", "key", constants.CtxParamKey)
 		return
 	}
 
@@ -180,32 +188,84 @@ func writeLogV2(logFile, msg string) error {
 	return nil
 }
 
-// ReadLog from log file
-func ReadLog(logDateTim, logId int64, fromLineNum int32) (line int32, content string) {
+// ReadLog read a window of lines from the log file, start at fromLineNum(start from 1).
+//
+// Returns:
+//
+// - toLineNum is the last read line number, equals fromLineNum-1 on nothing read.
+// - eof will be true on read to the end of file. the last line without newline only read on finished=true,
+// because it may be still writing by the running task.
+func ReadLog(logDateTim, logId int64, fromLineNum int32, finished bool) (toLineNum int32, content string, eof bool) {
+	if fromLineNum < 1 {
+		fromLineNum = 1
+	}
+
 	nowTime := time.Unix(logDateTim/1000, 0)
 	pathPrefix := GetLogPath(nowTime)
+	toLineNum = fromLineNum - 1
 
 	// fileName := GetLogPath(nowTime) + "/" + fmt.Sprintf("%d", logId) + ".log"
-	fileName := pathPrefix + "_" + fmt.Sprintf("%d", logId) + ".log"
+	fileName := pathPrefix + "_" + LogfileName(logId)
 	file, err := os.Open(fileName)
-	totalLines := int32(1)
+	if err != nil {
+		return toLineNum, "", true
+	}
+	defer file.Close()
 
 	var buffer bytes.Buffer
-	if err == nil {
-		defer file.Close()
-
-		rd := bufio.NewReader(file)
-		for {
-			line, err := rd.ReadString('\n')
-			if err != nil || io.EOF == err {
-				break
+	lineNum := int32(0)
+	readLines := 0
+
+	rd := bufio.NewReader(file)
+	for {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			// the last line without newline
+			if err == io.EOF && line != "" && finished {
+				err = nil
+			} else {
+				return toLineNum, buffer.String(), err == io.EOF
 			}
+		}
 
-			if totalLines >= fromLineNum {
-				buffer.WriteString(line)
+		lineNum++
+		if lineNum < fromLineNum {
+			continue
+		}
+
+		if buffer.Len()+len(line) > ReadLogMaxBytes {
+			if buffer.Len() > 0 {
+				return toLineNum, buffer.String(), false
 			}
-			totalLines++
+
+			// single line too long, the log window is line based, can't return the rest in next window.
+			line = truncateLine(line, ReadLogMaxBytes)
+		}
+
+		buffer.WriteString(line)
+		toLineNum = lineNum
+		readLines++
+
+		if readLines >= ReadLogMaxLines {
+			// check has more contents
+			_, err = rd.Peek(1)
+			return toLineNum, buffer.String(), err == io.EOF
 		}
 	}
-	return totalLines, buffer.String()
+}
+
+// lineTruncatedFmt the notice for the too long line
+const lineTruncatedFmt = " ...[xxl-job] the line is too long, discarded %d bytes\n"
+
+// truncateLine cut the too long line on the rune boundary, with a truncation notice.
+func truncateLine(line string, maxBytes int) string {
+	// reserve space for the notice
+	end := maxBytes - len(lineTruncatedFmt) - 16
+	if end < 0 {
+		end = 0
+	}
+	for end > 0 && !utf8.RuneStart(line[end]) {
+		end--
+	}
+	return line[:end] + fmt.Sprintf(lineTruncatedFmt, len(line)-end)
 }
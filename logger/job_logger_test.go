@@ -0,0 +1,77 @@
+package logger_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xxl-job-logs")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	logger.SetLogBasePath(dir)
+
+	logId := int64(1001)
+	logTime := time.Now().Unix() * 1000
+
+	var sb strings.Builder
+	for i := 1; i <= logger.ReadLogMaxLines+10; i++ {
+		sb.WriteString(fmt.Sprintf("line %d\n", i))
+	}
+	sb.WriteString("partial line")
+	assert.NoError(t, ioutil.WriteFile(logger.LogfilePath(logId), []byte(sb.String()), 0644))
+
+	// first window
+	toLine, content, eof := logger.ReadLog(logTime, logId, 1, false)
+	assert.Equal(t, int32(logger.ReadLogMaxLines), toLine)
+	assert.True(t, strings.HasPrefix(content, "line 1\n"))
+	assert.False(t, eof)
+
+	// task is running, not read the partial last line
+	toLine, content, eof = logger.ReadLog(logTime, logId, toLine+1, false)
+	assert.Equal(t, int32(logger.ReadLogMaxLines+10), toLine)
+	assert.NotContains(t, content, "partial line")
+	assert.True(t, eof)
+
+	// task is finished
+	toLine, content, eof = logger.ReadLog(logTime, logId, toLine+1, true)
+	assert.Equal(t, int32(logger.ReadLogMaxLines+11), toLine)
+	assert.Equal(t, "partial line", content)
+	assert.True(t, eof)
+
+	// not exists
+	toLine, content, eof = logger.ReadLog(logTime, 1002, 1, true)
+	assert.Equal(t, int32(0), toLine)
+	assert.Equal(t, "", content)
+	assert.True(t, eof)
+}
+
+func TestReadLog_longLine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xxl-job-logs")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	logger.SetLogBasePath(dir)
+
+	logId := int64(1003)
+	logTime := time.Now().Unix() * 1000
+
+	// multi bytes chars, the cut position may be in a rune
+	long := strings.Repeat("中", logger.ReadLogMaxBytes/3+10) + "\n"
+	assert.NoError(t, ioutil.WriteFile(logger.LogfilePath(logId), []byte(long+"next line\n"), 0644))
+
+	toLine, content, eof := logger.ReadLog(logTime, logId, 1, true)
+	assert.Equal(t, int32(2), toLine)
+	assert.True(t, utf8.ValidString(content))
+	assert.LessOrEqual(t, len(content), logger.ReadLogMaxBytes)
+	assert.Contains(t, content, "the line is too long, discarded")
+	assert.True(t, strings.HasSuffix(content, "\nnext line\n"))
+	assert.True(t, eof)
+}
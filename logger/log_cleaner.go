@@ -0,0 +1,236 @@
+package logger
+
+import (
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/constants"
+)
+
+var (
+	// job log file name. eg: 2022-01-02_234.log
+	logfileRegex = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})_(\d+)\.log$`)
+	// glue script file name. eg: job12_1641052800000.sh
+	gluefileRegex = regexp.MustCompile(`^job(\d+)_(\d+)\.\w+$`)
+)
+
+// DefaultCleanInterval default interval for clean the job logs
+const DefaultCleanInterval = time.Hour
+
+// LogCleaner periodically clean the expired job logs and the stale glue scripts.
+type LogCleaner struct {
+	// RetentionDays keep the job logs days. <= 0 is not clean by age.
+	RetentionDays int
+	// MaxTotalSize max total bytes of the job log files. <= 0 is not limit.
+	MaxTotalSize int64
+	// Interval for run clean. <= 0 will use DefaultCleanInterval
+	Interval time.Duration
+	// LogInUse check the task log is in use(task is waiting or running), will not remove it.
+	LogInUse func(logId int64) bool
+	// GlueInUse check the job glue script is in use(job has running task), will not remove it.
+	GlueInUse func(jobId int32) bool
+	// RemoveGlue remove the stale glue script, return false on it is in use. will replace the GlueInUse check.
+	// set it for sync with the glue scripts writing by the executor.
+	RemoveGlue func(gf GlueFile) (bool, error)
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	wg       sync.WaitGroup
+}
+
+type logFileInfo struct {
+	path    string
+	size    int64
+	modTime time.Time
+	logId   int64
+}
+
+// NewLogCleaner create
+func NewLogCleaner(retentionDays int, maxTotalSize int64, interval time.Duration) *LogCleaner {
+	return &LogCleaner{
+		RetentionDays: retentionDays,
+		MaxTotalSize:  maxTotalSize,
+		Interval:      interval,
+		stopCh:        make(chan struct{}),
+	}
+}
+
+// Start clean loop
+func (c *LogCleaner) Start() {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.Clean()
+
+		interval := c.Interval
+		if interval <= 0 {
+			interval = DefaultCleanInterval
+		}
+
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-c.stopCh:
+				return
+			case <-t.C:
+				c.Clean()
+			}
+		}
+	}()
+}
+
+// Stop clean loop
+func (c *LogCleaner) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stopCh)
+	})
+	c.wg.Wait()
+}
+
+// Clean expired job logs and the stale glue scripts, return removed file number.
+func (c *LogCleaner) Clean() (removed int) {
+	removed = c.cleanLogs() + c.cleanGlues()
+	if removed > 0 {
+		Info("log cleaner removed expired job logs and glue scripts", "removed", removed)
+	}
+	return
+}
+
+func (c *LogCleaner) cleanLogs() (removed int) {
+	files, err := ioutil.ReadDir(LogBasePath())
+	if err != nil {
+		Error("log cleaner read log dir failed", "error", err)
+		return
+	}
+
+	expireDate := c.expireDate()
+	keeps := make([]logFileInfo, 0, len(files))
+	var totalSize int64
+
+	for _, fi := range files {
+		if fi.IsDir() {
+			continue
+		}
+
+		ss := logfileRegex.FindStringSubmatch(fi.Name())
+		if len(ss) == 0 {
+			continue
+		}
+
+		logId, _ := strconv.ParseInt(ss[2], 10, 64)
+		info := logFileInfo{
+			path:    LogBasePath() + "/" + fi.Name(),
+			size:    fi.Size(),
+			modTime: fi.ModTime(),
+			logId:   logId,
+		}
+
+		if expireDate != "" && ss[1] < expireDate && c.removeLog(info) {
+			removed++
+			continue
+		}
+
+		keeps = append(keeps, info)
+		totalSize += info.size
+	}
+
+	if c.MaxTotalSize <= 0 || totalSize <= c.MaxTotalSize {
+		return
+	}
+
+	// remove the oldest logs until total size is less than MaxTotalSize
+	sort.Slice(keeps, func(i, j int) bool {
+		return keeps[i].modTime.Before(keeps[j].modTime)
+	})
+
+	for _, info := range keeps {
+		if totalSize <= c.MaxTotalSize {
+			break
+		}
+
+		if c.removeLog(info) {
+			removed++
+			totalSize -= info.size
+		}
+	}
+	return
+}
+
+func (c *LogCleaner) removeLog(info logFileInfo) bool {
+	if c.LogInUse != nil && c.LogInUse(info.logId) {
+		return false
+	}
+
+	if err := os.Remove(info.path); err != nil {
+		Error("log cleaner remove file failed", "file", info.path, "logId", info.logId, "error", err)
+		return false
+	}
+	return true
+}
+
+// cleanGlues remove the superseded glue script versions,
+// and the glue scripts not updated in retention days.
+func (c *LogCleaner) cleanGlues() (removed int) {
+	files, err := ListGlueFiles()
+	if err != nil {
+		return
+	}
+
+	// files are sorted by updateTime, the last one is latest
+	latest := make(map[int32]GlueFile)
+	var stales []GlueFile
+	for _, gf := range files {
+		if old, ok := latest[gf.JobId]; ok {
+			stales = append(stales, old)
+		}
+		latest[gf.JobId] = gf
+	}
+
+	// the latest version is not updated in retention days
+	if c.RetentionDays > 0 {
+		expireTime := time.Now().AddDate(0, 0, -c.RetentionDays)
+		for _, gf := range latest {
+			if gf.ModTime.Before(expireTime) {
+				stales = append(stales, gf)
+			}
+		}
+	}
+
+	for _, gf := range stales {
+		ok, err := c.removeGlue(gf)
+		if err != nil {
+			Error("log cleaner remove glue file failed", "file", gf.Name, "jobId", gf.JobId, "error", err)
+			continue
+		}
+		if ok {
+			removed++
+		}
+	}
+	return
+}
+
+func (c *LogCleaner) removeGlue(gf GlueFile) (bool, error) {
+	if c.RemoveGlue != nil {
+		return c.RemoveGlue(gf)
+	}
+
+	if c.GlueInUse != nil && c.GlueInUse(gf.JobId) {
+		return false, nil
+	}
+	return true, os.Remove(gf.Path)
+}
+
+// expireDate the logs before this date will be removed
+func (c *LogCleaner) expireDate() string {
+	if c.RetentionDays <= 0 {
+		return ""
+	}
+	return time.Now().AddDate(0, 0, -c.RetentionDays).Format(constants.DateFormat)
+}
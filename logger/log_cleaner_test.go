@@ -0,0 +1,77 @@
+package logger_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/constants"
+	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+	"github.com/gookit/goutil/fsutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogCleaner_Clean(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xxl-job-logs")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	logger.SetLogBasePath(dir)
+
+	oldDate := time.Now().AddDate(0, 0, -10).Format(constants.DateFormat)
+	today := time.Now().Format(constants.DateFormat)
+	files := map[string]string{
+		oldDate + "_1.log": "expired",
+		oldDate + "_2.log": "expired, but task running",
+		today + "_3.log":   "keep",
+		"other.txt":        "not job log",
+	}
+	for name, text := range files {
+		assert.NoError(t, ioutil.WriteFile(dir+"/"+name, []byte(text), 0644))
+	}
+
+	assert.NoError(t, os.MkdirAll(logger.GlueSourcePath(), os.ModePerm))
+	for _, name := range []string{"job1_100.sh", "job1_200.sh", "job2_100.py", "job2_200.py"} {
+		assert.NoError(t, ioutil.WriteFile(logger.GlueSourcePath()+"/"+name, []byte("echo hi"), 0644))
+	}
+
+	lc := logger.NewLogCleaner(3, 0, time.Hour)
+	lc.LogInUse = func(logId int64) bool {
+		return logId == 2
+	}
+	lc.GlueInUse = func(jobId int32) bool {
+		return jobId == 2
+	}
+
+	assert.Equal(t, 2, lc.Clean())
+	assert.False(t, fsutil.FileExists(dir+"/"+oldDate+"_1.log"))
+	assert.True(t, fsutil.FileExists(dir+"/"+oldDate+"_2.log"))
+	assert.True(t, fsutil.FileExists(dir+"/"+today+"_3.log"))
+	assert.True(t, fsutil.FileExists(dir+"/other.txt"))
+
+	assert.False(t, fsutil.FileExists(logger.GlueSourcePath()+"/job1_100.sh"))
+	assert.True(t, fsutil.FileExists(logger.GlueSourcePath()+"/job1_200.sh"))
+	assert.True(t, fsutil.FileExists(logger.GlueSourcePath()+"/job2_100.py"))
+
+	// limit total size, remove the oldest logs
+	oldTime := time.Now().Add(-time.Hour)
+	assert.NoError(t, os.Chtimes(dir+"/"+oldDate+"_2.log", oldTime, oldTime))
+
+	lc = logger.NewLogCleaner(0, 5, time.Hour)
+	assert.Equal(t, 2, lc.Clean())
+	assert.False(t, fsutil.FileExists(logger.GlueSourcePath()+"/job2_100.py"))
+	assert.False(t, fsutil.FileExists(dir+"/"+oldDate+"_2.log"))
+	assert.True(t, fsutil.FileExists(dir+"/"+today+"_3.log"))
+}
+
+func TestLogCleaner_Start_zeroInterval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xxl-job-logs")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	logger.SetLogBasePath(dir)
+
+	// will use the default interval, not panic
+	c := logger.NewLogCleaner(7, 0, 0)
+	c.Start()
+	c.Stop()
+}
@@ -4,42 +4,155 @@ package logger
 import (
 	"fmt"
 	"log"
+	"strings"
+	"sync/atomic"
 
-	"github.com/goft-cloud/go-xxl-job-client/v2/option"
 	"github.com/gookit/color"
 )
 
+// Logger interface for output the diagnostics logs. can be replaced by SetLogger()
+//
+// kvs is key-value pairs of the log fields. eg: "jobId", 12, "logId", 234
+type Logger interface {
+	Debug(msg string, kvs ...interface{})
+	Info(msg string, kvs ...interface{})
+	Warn(msg string, kvs ...interface{})
+	Error(msg string, kvs ...interface{})
+}
+
+// holder for atomic store the Logger
+type holder struct {
+	Logger
+}
+
+var (
+	std Logger = &StdLogger{}
+	// current logger
+	current atomic.Value
+	// debugMode 1 on enable debug logs
+	debugMode int32
+)
+
+func init() {
+	current.Store(holder{std})
+}
+
+// SetLogger set the logger, reset to default on l is nil
+func SetLogger(l Logger) {
+	if l == nil {
+		l = std
+	}
+	current.Store(holder{l})
+}
+
+// GetLogger get current logger
+func GetLogger() Logger {
+	return current.Load().(holder).Logger
+}
+
+// SetDebugMode enable or disable output debug logs of the StdLogger.
+// the custom logger always receive the debug logs, it should filter them by its level.
+func SetDebugMode(enable bool) {
+	var val int32
+	if enable {
+		val = 1
+	}
+	atomic.StoreInt32(&debugMode, val)
+}
+
+// IsDebugMode check
+func IsDebugMode() bool {
+	return atomic.LoadInt32(&debugMode) == 1
+}
+
+// StdLogger the default logger, output logs by the standard log package.
+type StdLogger struct{}
+
+// Debug log, only output on debug mode.
+func (l *StdLogger) Debug(msg string, kvs ...interface{}) {
+	if !IsDebugMode() {
+		return
+	}
+	log.Println(color.Render("<lightBlue>[XXL-DEBUG]</>"), formatKvs(msg, kvs))
+}
+
 // Info log
-func Info(msg string) {
-	log.Println(color.Render("[<info>XXL-INFO</>]"), msg)
+func (l *StdLogger) Info(msg string, kvs ...interface{}) {
+	log.Println(color.Render("[<info>XXL-INFO</>]"), formatKvs(msg, kvs))
 }
 
-// Infof log
-func Infof(tpl string, args ...interface{}) {
-	// log.Println("[XXL-INFO]", fmt.Sprintf(tpl, args...))
-	log.Println(color.Render("[<info>XXL-INFO</>]"), fmt.Sprintf(tpl, args...))
+// Warn log
+func (l *StdLogger) Warn(msg string, kvs ...interface{}) {
+	log.Println(color.Render("[<warn>XXL-WARN</>]"), formatKvs(msg, kvs))
 }
 
 // Error log
-func Error(msg string) {
-	log.Println(color.Render("[<err>XXL-ERROR</>]"), msg)
+func (l *StdLogger) Error(msg string, kvs ...interface{}) {
+	log.Println(color.Render("[<err>XXL-ERROR</>]"), formatKvs(msg, kvs))
 }
 
-// Errorf log
-func Errorf(tpl string, args ...interface{}) {
-	log.Println(color.Render("[<err>XXL-ERROR</>]"), fmt.Sprintf(tpl, args...))
-}
+// formatKvs append key-value pairs to message. eg: "msg key1=val1 key2=val2"
+func formatKvs(msg string, kvs []interface{}) string {
+	if len(kvs) == 0 {
+		return msg
+	}
 
-// Debug log
-func Debug(msg string) {
-	if option.IsDebugMode() {
-		log.Println(color.Render("<lightBlue>[XXL-DEBUG]</>"), msg)
+	var sb strings.Builder
+	sb.WriteString(msg)
+	for i := 0; i < len(kvs); i += 2 {
+		sb.WriteByte(' ')
+		sb.WriteString(fmt.Sprint(kvs[i]))
+		sb.WriteByte('=')
+
+		if i+1 < len(kvs) {
+			sb.WriteString(fmt.Sprint(kvs[i+1]))
+		} else {
+			sb.WriteString("<missing>")
+		}
 	}
+	return sb.String()
+}
+
+// Debug log. the default logger only output it on debug mode.
+func Debug(msg string, kvs ...interface{}) {
+	GetLogger().Debug(msg, kvs...)
 }
 
-// Debugf log
+// Debugf log. the default logger only output it on debug mode.
 func Debugf(tpl string, args ...interface{}) {
-	if option.IsDebugMode() {
-		log.Println(color.Render("<lightBlue>[XXL-DEBUG]</>"), fmt.Sprintf(tpl, args...))
+	l := GetLogger()
+	if l == std && !IsDebugMode() {
+		return // skip format
 	}
+	l.Debug(fmt.Sprintf(tpl, args...))
+}
+
+// Info log
+func Info(msg string, kvs ...interface{}) {
+	GetLogger().Info(msg, kvs...)
+}
+
+// Infof log
+func Infof(tpl string, args ...interface{}) {
+	GetLogger().Info(fmt.Sprintf(tpl, args...))
+}
+
+// Warn log
+func Warn(msg string, kvs ...interface{}) {
+	GetLogger().Warn(msg, kvs...)
+}
+
+// Warnf log
+func Warnf(tpl string, args ...interface{}) {
+	GetLogger().Warn(fmt.Sprintf(tpl, args...))
+}
+
+// Error log
+func Error(msg string, kvs ...interface{}) {
+	GetLogger().Error(msg, kvs...)
+}
+
+// Errorf log
+func Errorf(tpl string, args ...interface{}) {
+	GetLogger().Error(fmt.Sprintf(tpl, args...))
 }
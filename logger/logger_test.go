@@ -0,0 +1,45 @@
+package logger_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+type testLogger struct {
+	lines []string
+}
+
+func (l *testLogger) log(level, msg string, kvs []interface{}) {
+	l.lines = append(l.lines, fmt.Sprint(level, " ", msg, " ", kvs))
+}
+
+func (l *testLogger) Debug(msg string, kvs ...interface{}) { l.log("DEBUG", msg, kvs) }
+func (l *testLogger) Info(msg string, kvs ...interface{})  { l.log("INFO", msg, kvs) }
+func (l *testLogger) Warn(msg string, kvs ...interface{})  { l.log("WARN", msg, kvs) }
+func (l *testLogger) Error(msg string, kvs ...interface{}) { l.log("ERROR", msg, kvs) }
+
+func TestSetLogger(t *testing.T) {
+	tl := &testLogger{}
+	logger.SetLogger(tl)
+	defer logger.SetLogger(nil)
+
+	logger.Info("job started", "jobId", 12, "logId", 234)
+	logger.Errorf("job#%d failed", 12)
+
+	// the custom logger always receive debug logs, filter them by its level
+	logger.Debug("debug message")
+	logger.Debugf("debug %s", "format")
+
+	assert.Equal(t, []string{
+		"INFO job started [jobId 12 logId 234]",
+		"ERROR job#12 failed []",
+		"DEBUG debug message []",
+		"DEBUG debug format []",
+	}, tl.lines)
+
+	_, ok := logger.GetLogger().(*testLogger)
+	assert.True(t, ok)
+}
@@ -0,0 +1,159 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/constants"
+)
+
+// the output stream names
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// OutputDrainTimeout wait time for read the remaining output after the process exited.
+// the background sub-processes may still hold the pipes.
+var OutputDrainTimeout = 2 * time.Second
+
+// max bytes of an output line, the longer line will be split.
+const outputLineMaxLen = 64 * 1024
+
+// TaskOutput write the process output to the task log file, by line.
+//
+// each line with the time and stream prefix. eg: "2022-01-02 15:04:05 [stderr] error message"
+type TaskOutput struct {
+	mu sync.Mutex
+	fh *os.File
+	// max bytes of the log file, <= 0 is not limit.
+	maxSize   int64
+	size      int64
+	truncated bool
+
+	// the pipe writer ends, pass to the process
+	writers []*os.File
+	readers []*os.File
+	wg      sync.WaitGroup
+}
+
+// NewTaskOutput open the task log file for write output.
+// the maxSize is max bytes of the whole log file, the exceeded output will be discarded.
+func NewTaskOutput(logfile string, maxSize int64) (*TaskOutput, error) {
+	fh, err := OpenLogFile(logfile)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := fh.Stat()
+	if err != nil {
+		_ = fh.Close()
+		return nil, err
+	}
+
+	return &TaskOutput{fh: fh, maxSize: maxSize, size: fi.Size()}, nil
+}
+
+// Pipe create a pipe for the stream, the returned file should be set as the process stdout or stderr.
+// the output will be read and written to the log file on the background.
+func (o *TaskOutput) Pipe(stream string) (*os.File, error) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	o.readers = append(o.readers, pr)
+	o.writers = append(o.writers, pw)
+
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		o.readLines(stream, pr)
+	}()
+	return pw, nil
+}
+
+func (o *TaskOutput) readLines(stream string, r io.Reader) {
+	br := bufio.NewReaderSize(r, outputLineMaxLen)
+	for {
+		line, err := br.ReadSlice('\n')
+		if len(line) > 0 {
+			o.writeLine(stream, bytes.TrimRight(line, "\r\n"))
+		}
+
+		if err != nil && err != bufio.ErrBufferFull {
+			return
+		}
+	}
+}
+
+func (o *TaskOutput) writeLine(stream string, line []byte) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.truncated {
+		return
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(time.Now().Format(constants.DateTimeFormat))
+	buf.WriteString(" [")
+	buf.WriteString(stream)
+	buf.WriteString("] ")
+	buf.Write(line)
+	buf.WriteByte('\n')
+
+	if o.maxSize > 0 && o.size+int64(buf.Len()) > o.maxSize {
+		o.truncated = true
+		buf.Reset()
+		buf.WriteString(fmt.Sprintf("%s [xxl-job] the output exceeded the max log size %d bytes, the rest is discarded\n",
+			time.Now().Format(constants.DateTimeFormat), o.maxSize))
+	}
+
+	n, err := o.fh.Write(buf.Bytes())
+	o.size += int64(n)
+	if err != nil {
+		Error("write the task output failed", "file", o.fh.Name(), "error", err.Error())
+	}
+}
+
+// Truncated check the output is truncated by exceeded the max size
+func (o *TaskOutput) Truncated() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.truncated
+}
+
+// Close the output. should be called after the process exited, will wait to read the remaining output.
+func (o *TaskOutput) Close() error {
+	// close the writer ends in current process, the readers will get EOF after the process exited.
+	for _, pw := range o.writers {
+		_ = pw.Close()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		o.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(OutputDrainTimeout):
+		// the pipes are held by the background sub-processes
+		for _, pr := range o.readers {
+			_ = pr.Close()
+		}
+		<-done
+	}
+
+	for _, pr := range o.readers {
+		_ = pr.Close()
+	}
+	return o.fh.Close()
+}
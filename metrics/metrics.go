@@ -0,0 +1,112 @@
+// Package metrics record the executor activity metrics.
+//
+// default recorder is noop, set a Registry(or your own Recorder) by SetRecorder for enable it.
+package metrics
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// the executor metric names
+const (
+	// TriggersTotal counter, the triggers received per handler. labels: handler
+	TriggersTotal = "xxl_job_triggers_total"
+	// KillsTotal counter, the tasks killed by admin per handler. labels: handler
+	KillsTotal = "xxl_job_kills_total"
+	// TasksTotal counter, the tasks executed result per handler. labels: handler, status
+	TasksTotal = "xxl_job_tasks_total"
+	// TaskDuration histogram, the task execute duration seconds. labels: handler
+	TaskDuration = "xxl_job_task_duration_seconds"
+	// QueueDepth gauge, the waiting tasks number per JobQueue. labels: job_id
+	QueueDepth = "xxl_job_queue_depth"
+	// CallbacksTotal counter, the task callbacks to admin. labels: status
+	CallbacksTotal = "xxl_job_callbacks_total"
+	// AdminRequestDuration histogram, the admin api request duration seconds. labels: api, address
+	AdminRequestDuration = "xxl_job_admin_request_duration_seconds"
+	// AdminRequestFailures counter, the admin api request failures. labels: api, address
+	AdminRequestFailures = "xxl_job_admin_request_failures_total"
+	// ActiveSessions gauge, the active getty sessions.
+	ActiveSessions = "xxl_job_active_sessions"
+	// LiveRunners gauge, the live job runner goroutines.
+	LiveRunners = "xxl_job_live_runners"
+)
+
+// task result status label values
+const (
+	StatusSuccess = "success"
+	StatusFail    = "fail"
+	StatusTimeout = "timeout"
+	StatusKilled  = "killed"
+)
+
+// Labels for metric
+type Labels map[string]string
+
+// Recorder interface, implement it for plug your own metrics registry.
+type Recorder interface {
+	// AddCounter add delta to the counter
+	AddCounter(name string, labels Labels, delta float64)
+	// SetGauge set the gauge value
+	SetGauge(name string, labels Labels, value float64)
+	// Observe add a value to the histogram
+	Observe(name string, labels Labels, value float64)
+	// Delete remove the series of the labels, call it on the labelled object is gone
+	Delete(name string, labels Labels)
+}
+
+type noopRecorder struct{}
+
+func (noopRecorder) AddCounter(string, Labels, float64) {}
+func (noopRecorder) SetGauge(string, Labels, float64)   {}
+func (noopRecorder) Observe(string, Labels, float64)    {}
+func (noopRecorder) Delete(string, Labels)              {}
+
+// holder for atomic store the Recorder
+type holder struct {
+	Recorder
+}
+
+var current atomic.Value
+
+func init() {
+	current.Store(holder{noopRecorder{}})
+}
+
+// SetRecorder set the metrics recorder, reset to noop on r is nil
+func SetRecorder(r Recorder) {
+	if r == nil {
+		r = noopRecorder{}
+	}
+	current.Store(holder{r})
+}
+
+// GetRecorder get current recorder
+func GetRecorder() Recorder {
+	return current.Load().(holder).Recorder
+}
+
+// Inc the counter
+func Inc(name string, labels Labels) {
+	GetRecorder().AddCounter(name, labels, 1)
+}
+
+// Add delta to the counter
+func Add(name string, labels Labels, delta float64) {
+	GetRecorder().AddCounter(name, labels, delta)
+}
+
+// Set the gauge value
+func Set(name string, labels Labels, value float64) {
+	GetRecorder().SetGauge(name, labels, value)
+}
+
+// Delete remove the series of the labels
+func Delete(name string, labels Labels) {
+	GetRecorder().Delete(name, labels)
+}
+
+// ObserveDuration add the seconds since start to the histogram
+func ObserveDuration(name string, labels Labels, start time.Time) {
+	GetRecorder().Observe(name, labels, time.Since(start).Seconds())
+}
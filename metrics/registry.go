@@ -0,0 +1,261 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+)
+
+// DefaultBuckets for histogram, unit is seconds
+var DefaultBuckets = []float64{0.005, 0.01, 0.05, 0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 300, 600}
+
+var metricHelps = map[string]string{
+	TriggersTotal:        "Total number of job triggers received.",
+	KillsTotal:           "Total number of job tasks killed by admin.",
+	TasksTotal:           "Total number of job tasks executed, by result status.",
+	TaskDuration:         "Job task execute duration in seconds.",
+	QueueDepth:           "Number of the waiting tasks in job queue.",
+	CallbacksTotal:       "Total number of task callbacks to admin, by result status.",
+	AdminRequestDuration: "Admin api request duration in seconds.",
+	AdminRequestFailures: "Total number of admin api request failures.",
+	ActiveSessions:       "Number of the active getty sessions.",
+	LiveRunners:          "Number of the live job runner goroutines.",
+}
+
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// Registry a simple Recorder implementation, can export metrics by the prometheus text format.
+type Registry struct {
+	mu       sync.Mutex
+	buckets  []float64
+	families map[string]*family
+}
+
+type family struct {
+	typ    string
+	series map[string]*series
+}
+
+type series struct {
+	labels string
+	value  float64
+	// for histogram
+	counts []uint64
+	count  uint64
+}
+
+// NewRegistry create. will use DefaultBuckets on buckets is empty
+func NewRegistry(buckets ...float64) *Registry {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	sort.Float64s(buckets)
+	return &Registry{
+		buckets:  buckets,
+		families: make(map[string]*family),
+	}
+}
+
+// AddCounter add delta to the counter
+func (r *Registry) AddCounter(name string, labels Labels, delta float64) {
+	r.mu.Lock()
+	r.series(name, typeCounter, labels).value += delta
+	r.mu.Unlock()
+}
+
+// SetGauge set the gauge value
+func (r *Registry) SetGauge(name string, labels Labels, value float64) {
+	r.mu.Lock()
+	r.series(name, typeGauge, labels).value = value
+	r.mu.Unlock()
+}
+
+// Observe add a value to the histogram
+func (r *Registry) Observe(name string, labels Labels, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := r.series(name, typeHistogram, labels)
+	if s.counts == nil {
+		s.counts = make([]uint64, len(r.buckets))
+	}
+
+	for i, le := range r.buckets {
+		if value <= le {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.value += value
+}
+
+// Delete remove the series of the labels, the family is removed on has no series.
+func (r *Registry) Delete(name string, labels Labels) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.families[name]
+	if !ok {
+		return
+	}
+
+	delete(f.series, formatLabels(labels))
+	if len(f.series) == 0 {
+		delete(r.families, name)
+	}
+}
+
+func (r *Registry) series(name, typ string, labels Labels) *series {
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{typ: typ, series: make(map[string]*series)}
+		r.families[name] = f
+	}
+
+	key := formatLabels(labels)
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labels: key}
+		f.series[key] = s
+	}
+	return s
+}
+
+// WriteTo write all metrics by the prometheus text exposition format
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bw := &countWriter{w: bufio.NewWriter(w)}
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := r.families[name]
+		if help, ok := metricHelps[name]; ok {
+			bw.writeString("# HELP " + name + " " + help + "\n")
+		}
+		bw.writeString("# TYPE " + name + " " + f.typ + "\n")
+
+		keys := make([]string, 0, len(f.series))
+		for key := range f.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			s := f.series[key]
+			if f.typ != typeHistogram {
+				bw.writeString(name + wrapLabels(s.labels) + " " + formatFloat(s.value) + "\n")
+				continue
+			}
+
+			for i, le := range r.buckets {
+				bw.writeString(name + "_bucket" + wrapLabels(joinLabels(s.labels, `le="`+formatFloat(le)+`"`)))
+				bw.writeString(" " + strconv.FormatUint(s.counts[i], 10) + "\n")
+			}
+			bw.writeString(name + "_bucket" + wrapLabels(joinLabels(s.labels, `le="+Inf"`)))
+			bw.writeString(" " + strconv.FormatUint(s.count, 10) + "\n")
+			bw.writeString(name + "_sum" + wrapLabels(s.labels) + " " + formatFloat(s.value) + "\n")
+			bw.writeString(name + "_count" + wrapLabels(s.labels) + " " + strconv.FormatUint(s.count, 10) + "\n")
+		}
+	}
+
+	if bw.err == nil {
+		bw.err = bw.w.Flush()
+	}
+	return bw.n, bw.err
+}
+
+// ServeHTTP export metrics, implement the http.Handler
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = r.WriteTo(w)
+}
+
+// Serve the metrics handler on a side port. path is /metrics
+func Serve(addr string, handler http.Handler) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", handler)
+
+	srv := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("metrics server listen failed", "addr", addr, "error", err)
+		}
+	}()
+	return srv
+}
+
+type countWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countWriter) writeString(s string) {
+	if cw.err != nil {
+		return
+	}
+
+	n, err := cw.w.WriteString(s)
+	cw.n += int64(n)
+	cw.err = err
+}
+
+// formatLabels to string, sorted by label name. eg: `handler="demo",status="success"`
+func formatLabels(labels Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ss := make([]string, 0, len(names))
+	for _, name := range names {
+		ss = append(ss, name+`="`+escapeLabel(labels[name])+`"`)
+	}
+	return strings.Join(ss, ",")
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeLabel(val string) string {
+	return labelEscaper.Replace(val)
+}
+
+func joinLabels(labels, label string) string {
+	if labels == "" {
+		return label
+	}
+	return labels + "," + label
+}
+
+func wrapLabels(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
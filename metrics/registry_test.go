@@ -0,0 +1,70 @@
+package metrics_test
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	r := metrics.NewRegistry(0.1, 1)
+	metrics.SetRecorder(r)
+	defer metrics.SetRecorder(nil)
+
+	metrics.Inc(metrics.TriggersTotal, metrics.Labels{"handler": "demo"})
+	metrics.Inc(metrics.TriggersTotal, metrics.Labels{"handler": "demo"})
+	metrics.Set(metrics.ActiveSessions, nil, 3)
+	metrics.Set(metrics.LiveRunners, nil, 2)
+	metrics.GetRecorder().Observe(metrics.TaskDuration, metrics.Labels{"handler": `a"b`}, 0.5)
+
+	buf := new(bytes.Buffer)
+	_, err := r.WriteTo(buf)
+	assert.NoError(t, err)
+
+	out := buf.String()
+	assert.Contains(t, out, "# TYPE xxl_job_triggers_total counter\n")
+	assert.Contains(t, out, `xxl_job_triggers_total{handler="demo"} 2`+"\n")
+	assert.Contains(t, out, "xxl_job_active_sessions 3\n")
+	assert.Contains(t, out, "# HELP xxl_job_live_runners Number of the live job runner goroutines.\n")
+	assert.Contains(t, out, "# TYPE xxl_job_task_duration_seconds histogram\n")
+	assert.Contains(t, out, `xxl_job_task_duration_seconds_bucket{handler="a\"b",le="0.1"} 0`+"\n")
+	assert.Contains(t, out, `xxl_job_task_duration_seconds_bucket{handler="a\"b",le="1"} 1`+"\n")
+	assert.Contains(t, out, `xxl_job_task_duration_seconds_bucket{handler="a\"b",le="+Inf"} 1`+"\n")
+	assert.Contains(t, out, `xxl_job_task_duration_seconds_count{handler="a\"b"} 1`+"\n")
+}
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	r := metrics.NewRegistry()
+	r.Observe(metrics.AdminRequestDuration, metrics.Labels{"api": "callback"}, time.Second.Seconds())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, w.Body.String(), `xxl_job_admin_request_duration_seconds_sum{api="callback"} 1`)
+}
+
+func TestRegistry_Delete(t *testing.T) {
+	r := metrics.NewRegistry()
+	r.SetGauge(metrics.QueueDepth, metrics.Labels{"job_id": "1"}, 2)
+	r.SetGauge(metrics.QueueDepth, metrics.Labels{"job_id": "2"}, 3)
+
+	r.Delete(metrics.QueueDepth, metrics.Labels{"job_id": "1"})
+	buf := new(bytes.Buffer)
+	_, err := r.WriteTo(buf)
+	assert.NoError(t, err)
+	assert.NotContains(t, buf.String(), `xxl_job_queue_depth{job_id="1"}`)
+	assert.Contains(t, buf.String(), `xxl_job_queue_depth{job_id="2"} 3`)
+
+	// the family is removed with the last series
+	r.Delete(metrics.QueueDepth, metrics.Labels{"job_id": "2"})
+	buf.Reset()
+	_, err = r.WriteTo(buf)
+	assert.NoError(t, err)
+	assert.NotContains(t, buf.String(), "xxl_job_queue_depth")
+}
@@ -3,6 +3,7 @@ package option
 import (
 	"strings"
 
+	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
 	"github.com/gookit/goutil/strutil"
 )
 
@@ -22,9 +23,10 @@ const (
 // default is release mode
 var runMode = ModeRelease
 
-// SetRunMode type
+// SetRunMode type. on debug mode, the default logger will output the debug logs.
 func SetRunMode(mt modeType) {
 	runMode = mt
+	logger.SetDebugMode(mt == ModeDebug)
 }
 
 // SetRunModeByString type
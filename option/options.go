@@ -4,6 +4,10 @@ import (
 	"time"
 
 	"github.com/goft-cloud/go-xxl-job-client/v2/constants"
+	"github.com/goft-cloud/go-xxl-job-client/v2/handler"
+	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+	"github.com/goft-cloud/go-xxl-job-client/v2/metrics"
+	"github.com/goft-cloud/go-xxl-job-client/v2/tracing"
 )
 
 const (
@@ -12,6 +16,10 @@ const (
 	defaultPort      = 8081
 	defaultTimeout   = 5 * time.Second
 	defaultBeatTime  = 20 * time.Second
+	// default wait time for the running tasks on shutdown
+	defaultShutdownTimeout = 10 * time.Second
+	// default interval for clean expired job logs
+	defaultLogCleanInterval = logger.DefaultCleanInterval
 )
 
 // OptionFunc func
@@ -27,10 +35,34 @@ type ClientOptions struct {
 	AccessToken string
 	// LogBasePath the job logs base dir path.
 	LogBasePath string
+	// LogRetentionDays keep the job logs days, will remove the expired logs. <= 0 is not clean by age.
+	LogRetentionDays int
+	// LogMaxTotalSize max total bytes of the job logs, will remove the oldest logs on exceeded. <= 0 is not limit.
+	LogMaxTotalSize int64
+	// LogCleanInterval interval for clean job logs. default is 1 hour
+	LogCleanInterval time.Duration
 	// AppName 执行器名
 	AppName string
-	// ShellBin shell bin file. default is bash.
+	// ShellBin shell bin file of GLUE_SHELL. empty is keep the glue type default bash.
 	ShellBin string
+	// GlueTypes add or override the script glue types
+	GlueTypes []handler.GlueType
+	// ScriptEnv custom env vars for all script jobs
+	ScriptEnv map[string]string
+	// DisableShardArgs not append the shard index and total to the script args, use the env instead.
+	DisableShardArgs bool
+	// ScriptKillGrace wait time before SIGKILL the script process group on job stop. default is 5s
+	ScriptKillGrace time.Duration
+	// ScriptLimits the resource limits for all script jobs, only supported on linux
+	ScriptLimits handler.ResourceLimits
+	// GlueLimits the resource limits by glue type, will override the ScriptLimits
+	GlueLimits map[string]handler.ResourceLimits
+	// ScriptWorkDir the working dir for run the script task. support vars: {jobId}, {logId}
+	ScriptWorkDir string
+	// ScriptCredential run the script task as the user and group
+	ScriptCredential *handler.Credential
+	// ScriptMaxLogSize max bytes of the script task log, the exceeded output will be discarded. default not limit
+	ScriptMaxLogSize int64
 	// ClientPort client 执行器端口
 	ClientPort int
 	// EnableHttp 开启http协议
@@ -39,6 +71,22 @@ type ClientOptions struct {
 	Timeout time.Duration
 	// BeatTime 执行器续约时间（超过30秒不续约admin会移除执行器，请设置到30秒以内）
 	BeatTime time.Duration
+	// Logger for output the diagnostics logs. default is logger.StdLogger
+	Logger logger.Logger
+	// ShutdownTimeout max wait time for the running tasks completed on Run() received close signals.
+	ShutdownTimeout time.Duration
+	// Metrics recorder for record the executor activity. default is disabled
+	Metrics metrics.Recorder
+	// MetricsAddr serve the metrics text exposition on this address. eg: ":9101"
+	//
+	// will use metrics.Registry if Metrics is not set. the Metrics must implement http.Handler
+	MetricsAddr string
+	// Tracer for create spans around job execution. default is noop
+	Tracer tracing.Tracer
+	// JobIdleCycles the idle job queue will be evicted after the idle check cycles. <= 0 is not evict. default is 30
+	JobIdleCycles int
+	// JobIdleInterval interval of the idle check cycle. default is 3s
+	JobIdleInterval time.Duration
 }
 
 // NewClientOptions instance
@@ -48,12 +96,18 @@ func NewClientOptions(opts ...OptionFunc) ClientOptions {
 		AppName:  defaultAppName,
 		Timeout:  defaultTimeout,
 		BeatTime: defaultBeatTime,
-		ShellBin: constants.ShellBash,
+		// wait running tasks on shutdown
+		ShutdownTimeout: defaultShutdownTimeout,
 		// other
 		ClientPort:  defaultPort,
 		LogBasePath: constants.LogBasePath,
 		AdminAddr:   []string{defaultAdminAddr},
 		AccessToken: "",
+		// log cleaner
+		LogCleanInterval: defaultLogCleanInterval,
+		// evict idle job queues
+		JobIdleCycles:   handler.DefaultIdleCycles,
+		JobIdleInterval: handler.DefaultIdleInterval,
 	}
 
 	for _, o := range opts {
@@ -74,6 +128,29 @@ func WithLogBasePath(dirPath string) OptionFunc {
 	}
 }
 
+// WithLogRetentionDays keep the job logs days, expired logs and glue scripts will be removed
+func WithLogRetentionDays(days int) OptionFunc {
+	return func(o *ClientOptions) {
+		o.LogRetentionDays = days
+	}
+}
+
+// WithLogMaxTotalSize max total bytes of the job logs, the oldest logs will be removed on exceeded
+func WithLogMaxTotalSize(size int64) OptionFunc {
+	return func(o *ClientOptions) {
+		o.LogMaxTotalSize = size
+	}
+}
+
+// WithLogCleanInterval interval for clean the job logs. <= 0 will use the default interval
+func WithLogCleanInterval(interval time.Duration) OptionFunc {
+	return func(o *ClientOptions) {
+		if interval > 0 {
+			o.LogCleanInterval = interval
+		}
+	}
+}
+
 // WithAdminAddress xxl admin address
 func WithAdminAddress(addrs ...string) OptionFunc {
 	return func(o *ClientOptions) {
@@ -122,3 +199,119 @@ func WithEnableHttp(enable bool) OptionFunc {
 		o.EnableHttp = enable
 	}
 }
+
+// WithShutdownTimeout max wait time for the running tasks completed on shutdown
+func WithShutdownTimeout(timeout time.Duration) OptionFunc {
+	return func(o *ClientOptions) {
+		o.ShutdownTimeout = timeout
+	}
+}
+
+// WithLogger custom the logger for output the diagnostics logs
+func WithLogger(l logger.Logger) OptionFunc {
+	return func(o *ClientOptions) {
+		o.Logger = l
+	}
+}
+
+// WithMetrics custom the metrics recorder, eg: metrics.NewRegistry()
+func WithMetrics(rec metrics.Recorder) OptionFunc {
+	return func(o *ClientOptions) {
+		o.Metrics = rec
+	}
+}
+
+// WithMetricsAddr serve the metrics on a side port, path is /metrics
+func WithMetricsAddr(addr string) OptionFunc {
+	return func(o *ClientOptions) {
+		o.MetricsAddr = addr
+	}
+}
+
+// WithTracer custom the tracer, create spans around job execution
+func WithTracer(t tracing.Tracer) OptionFunc {
+	return func(o *ClientOptions) {
+		o.Tracer = t
+	}
+}
+
+// WithScriptEnv add custom env vars for all script jobs
+func WithScriptEnv(env map[string]string) OptionFunc {
+	return func(o *ClientOptions) {
+		if o.ScriptEnv == nil {
+			o.ScriptEnv = make(map[string]string, len(env))
+		}
+		for k, v := range env {
+			o.ScriptEnv[k] = v
+		}
+	}
+}
+
+// WithDisableShardArgs not append the shard args to script args
+func WithDisableShardArgs(disable bool) OptionFunc {
+	return func(o *ClientOptions) {
+		o.DisableShardArgs = disable
+	}
+}
+
+// WithScriptKillGrace set the grace period for stop the script process group
+func WithScriptKillGrace(d time.Duration) OptionFunc {
+	return func(o *ClientOptions) {
+		o.ScriptKillGrace = d
+	}
+}
+
+// WithScriptLimits set the resource limits for all script jobs
+func WithScriptLimits(limits handler.ResourceLimits) OptionFunc {
+	return func(o *ClientOptions) {
+		o.ScriptLimits = limits
+	}
+}
+
+// WithGlueLimits set the resource limits for the glue type. eg: "GLUE_PYTHON"
+func WithGlueLimits(glueType string, limits handler.ResourceLimits) OptionFunc {
+	return func(o *ClientOptions) {
+		if o.GlueLimits == nil {
+			o.GlueLimits = make(map[string]handler.ResourceLimits)
+		}
+		o.GlueLimits[glueType] = limits
+	}
+}
+
+// WithScriptWorkDir set the working dir for run the script task. eg: "/tmp/xxl-job/job{jobId}/{logId}"
+//
+// the per task dir(the path to {logId}) is removed after the task completed.
+func WithScriptWorkDir(dir string) OptionFunc {
+	return func(o *ClientOptions) {
+		o.ScriptWorkDir = dir
+	}
+}
+
+// WithScriptCredential run the script task as the user and group
+func WithScriptCredential(uid, gid uint32) OptionFunc {
+	return func(o *ClientOptions) {
+		o.ScriptCredential = &handler.Credential{Uid: uid, Gid: gid}
+	}
+}
+
+// WithGlueType add or override the script glue type. eg: GLUE_GROOVY
+func WithGlueType(gt handler.GlueType) OptionFunc {
+	return func(o *ClientOptions) {
+		o.GlueTypes = append(o.GlueTypes, gt)
+	}
+}
+
+// WithScriptMaxLogSize set the max bytes of the script task log
+func WithScriptMaxLogSize(size int64) OptionFunc {
+	return func(o *ClientOptions) {
+		o.ScriptMaxLogSize = size
+	}
+}
+
+// WithJobIdleEvict set the idle job queue evict config. cycles <= 0 is not evict.
+func WithJobIdleEvict(cycles int, interval time.Duration) OptionFunc {
+	return func(o *ClientOptions) {
+		o.JobIdleCycles = cycles
+		o.JobIdleInterval = interval
+	}
+}
@@ -0,0 +1,377 @@
+package param
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// the struct tag names for bind params
+const (
+	// TagName param name and options. eg: `param:"name,required"`, `param:"-"` for ignore field
+	TagName = "param"
+	// TagDefault default value on param not exists. eg: `default:"10s"`
+	TagDefault = "default"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// BindError the params bind and validate errors
+type BindError struct {
+	Errors []string
+}
+
+// Error string
+func (e *BindError) Error() string {
+	return "invalid job params: " + strings.Join(e.Errors, "; ")
+}
+
+func (e *BindError) add(format string, args ...interface{}) {
+	e.Errors = append(e.Errors, fmt.Sprintf(format, args...))
+}
+
+// Bind the input params to struct ptr.
+//
+// InputParam can be key=value lines, JSON object or YAML document. key=value lines support nested keys. eg: "db.host=localhost"
+//
+// Usage:
+//
+//	type MyParams struct {
+//		Name    string        `param:"name,required"`
+//		Limit   int           `param:"limit" default:"100"`
+//		Timeout time.Duration `param:"timeout" default:"30s"`
+//		Tags    []string      `param:"tags"` // tags=a,b,c
+//		DB      struct {
+//			Host string `param:"host" default:"localhost"`
+//		} `param:"db"`
+//	}
+func (cjp *CtxJobParam) Bind(ptr interface{}) error {
+	data, err := cjp.bindData()
+	if err != nil {
+		return err
+	}
+	return Bind(data, ptr)
+}
+
+// bindData build the bind data from input params
+func (cjp *CtxJobParam) bindData() (map[string]interface{}, error) {
+	if cjp.Data != nil {
+		return cjp.Data, nil
+	}
+
+	full := strings.TrimSpace(cjp.InputParam)
+	if strings.HasPrefix(full, "{") {
+		return decodeJSONObject(full)
+	}
+
+	if cjp.Values != nil {
+		data := make(map[string]interface{}, len(cjp.Values))
+		for key, vs := range cjp.Values {
+			if len(vs) == 1 {
+				setNested(data, key, vs[0])
+				continue
+			}
+
+			items := make([]interface{}, 0, len(vs))
+			for _, v := range vs {
+				items = append(items, v)
+			}
+			setNested(data, key, items)
+		}
+		return data, nil
+	}
+
+	data := make(map[string]interface{}, len(cjp.InputParams))
+	for key, val := range cjp.InputParams {
+		if key == "fullParam" {
+			continue
+		}
+		setNested(data, key, val)
+	}
+	return data, nil
+}
+
+// Bind the data map to struct ptr. see CtxJobParam.Bind()
+func Bind(data map[string]interface{}, ptr interface{}) error {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("bind params: the ptr must be a non-nil struct pointer")
+	}
+
+	be := &BindError{}
+	bindStruct(rv.Elem(), data, "", be)
+	if len(be.Errors) > 0 {
+		return be
+	}
+	return nil
+}
+
+func bindStruct(rv reflect.Value, data map[string]interface{}, prefix string, be *BindError) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if sf.PkgPath != "" { // not exported
+			continue
+		}
+
+		name, required := parseTag(sf)
+		if name == "-" {
+			continue
+		}
+
+		path := prefix + name
+		fv := rv.Field(i)
+		raw, ok := lookup(data, name)
+		if !ok {
+			if def, has := sf.Tag.Lookup(TagDefault); has {
+				raw, ok = def, true
+			}
+		}
+
+		if !ok {
+			if required {
+				be.add("param %q is required", path)
+			} else if isStruct(sf.Type) {
+				// apply the defaults and required of nested fields
+				setValue(fv, map[string]interface{}{}, path, be)
+			}
+			continue
+		}
+
+		setValue(fv, raw, path, be)
+	}
+}
+
+func setValue(fv reflect.Value, raw interface{}, path string, be *BindError) {
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		setValue(fv.Elem(), raw, path, be)
+		return
+	}
+
+	if fv.Type() == durationType {
+		d, err := toDuration(raw)
+		if err != nil {
+			be.add("param %q: invalid duration value %q", path, fmt.Sprint(raw))
+			return
+		}
+		fv.SetInt(int64(d))
+		return
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(fmt.Sprint(raw))
+	case reflect.Bool:
+		if b, ok := raw.(bool); ok {
+			fv.SetBool(b)
+			return
+		}
+
+		b, err := strconv.ParseBool(strings.TrimSpace(fmt.Sprint(raw)))
+		if err != nil {
+			be.add("param %q: invalid bool value %q", path, fmt.Sprint(raw))
+			return
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(fmt.Sprint(raw)), 10, fv.Type().Bits())
+		if err != nil {
+			be.add("param %q: invalid int value %q", path, fmt.Sprint(raw))
+			return
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(fmt.Sprint(raw)), 10, fv.Type().Bits())
+		if err != nil {
+			be.add("param %q: invalid uint value %q", path, fmt.Sprint(raw))
+			return
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(fmt.Sprint(raw)), fv.Type().Bits())
+		if err != nil {
+			be.add("param %q: invalid float value %q", path, fmt.Sprint(raw))
+			return
+		}
+		fv.SetFloat(f)
+	case reflect.Slice:
+		items := toSlice(raw)
+		sl := reflect.MakeSlice(fv.Type(), len(items), len(items))
+		for i, item := range items {
+			setValue(sl.Index(i), item, fmt.Sprintf("%s[%d]", path, i), be)
+		}
+		fv.Set(sl)
+	case reflect.Map:
+		if fv.Type().Key().Kind() != reflect.String {
+			be.add("param %q: unsupported map key type %s", path, fv.Type().Key())
+			return
+		}
+
+		m, err := toMap(raw)
+		if err != nil {
+			be.add("param %q: %s", path, err.Error())
+			return
+		}
+
+		mv := reflect.MakeMapWithSize(fv.Type(), len(m))
+		for key, item := range m {
+			ev := reflect.New(fv.Type().Elem()).Elem()
+			setValue(ev, item, path+"."+key, be)
+			mv.SetMapIndex(reflect.ValueOf(key).Convert(fv.Type().Key()), ev)
+		}
+		fv.Set(mv)
+	case reflect.Struct:
+		m, err := toMap(raw)
+		if err != nil {
+			be.add("param %q: %s", path, err.Error())
+			return
+		}
+		bindStruct(fv, m, path+".", be)
+	case reflect.Interface:
+		if raw == nil {
+			return
+		}
+
+		rv := reflect.ValueOf(raw)
+		if !rv.Type().AssignableTo(fv.Type()) {
+			be.add("param %q: value of type %s is not assignable to %s", path, rv.Type(), fv.Type())
+			return
+		}
+		fv.Set(rv)
+	default:
+		be.add("param %q: unsupported field type %s", path, fv.Type())
+	}
+}
+
+// parseTag get param name and required option. default name is the field name with lower first char.
+func parseTag(sf reflect.StructField) (name string, required bool) {
+	ss := strings.Split(sf.Tag.Get(TagName), ",")
+	name = strings.TrimSpace(ss[0])
+	for _, opt := range ss[1:] {
+		if strings.TrimSpace(opt) == "required" {
+			required = true
+		}
+	}
+
+	if name == "" {
+		rs := []rune(sf.Name)
+		rs[0] = unicode.ToLower(rs[0])
+		name = string(rs)
+	}
+	return
+}
+
+// lookup value by name, will fallback to case-insensitive match.
+func lookup(data map[string]interface{}, name string) (interface{}, bool) {
+	if val, ok := data[name]; ok {
+		return val, true
+	}
+
+	for key, val := range data {
+		if strings.EqualFold(key, name) {
+			return val, true
+		}
+	}
+	return nil, false
+}
+
+// setNested set value by dotted key. eg: "db.host" => {"db": {"host": val}}
+func setNested(data map[string]interface{}, key string, val interface{}) {
+	keys := strings.Split(key, ".")
+	node := data
+	for _, k := range keys[:len(keys)-1] {
+		sub, ok := node[k].(map[string]interface{})
+		if !ok {
+			if _, exists := node[k]; exists {
+				// conflict with a scalar value, keep the full key
+				data[key] = val
+				return
+			}
+
+			sub = make(map[string]interface{})
+			node[k] = sub
+		}
+		node = sub
+	}
+	node[keys[len(keys)-1]] = val
+}
+
+func isStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != durationType
+}
+
+// toDuration parse duration string. the number value is seconds.
+func toDuration(raw interface{}) (time.Duration, error) {
+	str := strings.TrimSpace(fmt.Sprint(raw))
+	if f, err := strconv.ParseFloat(str, 64); err == nil {
+		return time.Duration(f * float64(time.Second)), nil
+	}
+	return time.ParseDuration(str)
+}
+
+// toSlice convert value to slice. string value will split by comma.
+func toSlice(raw interface{}) []interface{} {
+	switch val := raw.(type) {
+	case []interface{}:
+		return val
+	case string:
+		val = strings.TrimSpace(val)
+		if strings.HasPrefix(val, "[") {
+			var items []interface{}
+			if err := json.Unmarshal([]byte(val), &items); err == nil {
+				return items
+			}
+		}
+
+		if val == "" {
+			return nil
+		}
+
+		ss := strings.Split(val, ",")
+		items := make([]interface{}, 0, len(ss))
+		for _, s := range ss {
+			items = append(items, strings.TrimSpace(s))
+		}
+		return items
+	default:
+		return []interface{}{raw}
+	}
+}
+
+// toMap convert value to map. string value will decode as JSON object.
+func toMap(raw interface{}) (map[string]interface{}, error) {
+	switch val := raw.(type) {
+	case map[string]interface{}:
+		return val, nil
+	case string:
+		if strings.TrimSpace(val) == "" {
+			return map[string]interface{}{}, nil
+		}
+		return decodeJSONObject(val)
+	default:
+		return nil, fmt.Errorf("invalid object value %q", fmt.Sprint(raw))
+	}
+}
+
+func decodeJSONObject(str string) (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(str)))
+	dec.UseNumber()
+
+	var data map[string]interface{}
+	if err := dec.Decode(&data); err != nil {
+		return nil, fmt.Errorf("invalid JSON object: %s", err.Error())
+	}
+	return data, nil
+}
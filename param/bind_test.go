@@ -0,0 +1,94 @@
+package param_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/param"
+	"github.com/stretchr/testify/assert"
+)
+
+type dbParams struct {
+	Host string `param:"host" default:"localhost"`
+	Port int    `param:"port" default:"3306"`
+}
+
+type testParams struct {
+	Name    string        `param:"name,required"`
+	Limit   int           `param:"limit" default:"100"`
+	Timeout time.Duration `param:"timeout" default:"30s"`
+	Debug   bool
+	Tags    []string `param:"tags"`
+	Ids     []int64  `param:"ids"`
+	DB      dbParams `param:"db"`
+	Ignore  string   `param:"-"`
+}
+
+func TestCtxJobParam_Bind_kv(t *testing.T) {
+	cjp := &param.CtxJobParam{InputParams: map[string]string{
+		"fullParam": "...",
+		"name":      "sync-users",
+		"timeout":   "1m",
+		"debug":     "true",
+		"tags":      "a, b,c",
+		"ids":       "1,2",
+		"db.host":   "10.0.0.1",
+		"Ignore":    "x",
+	}}
+
+	var p testParams
+	assert.NoError(t, cjp.Bind(&p))
+	assert.Equal(t, "sync-users", p.Name)
+	assert.Equal(t, 100, p.Limit)
+	assert.Equal(t, time.Minute, p.Timeout)
+	assert.True(t, p.Debug)
+	assert.Equal(t, []string{"a", "b", "c"}, p.Tags)
+	assert.Equal(t, []int64{1, 2}, p.Ids)
+	assert.Equal(t, dbParams{Host: "10.0.0.1", Port: 3306}, p.DB)
+	assert.Equal(t, "", p.Ignore)
+}
+
+func TestCtxJobParam_Bind_json(t *testing.T) {
+	cjp := &param.CtxJobParam{
+		InputParam: `{"name": "sync", "limit": 20, "timeout": 5, "tags": ["x"], "db": {"port": 3307}}`,
+	}
+
+	var p testParams
+	assert.NoError(t, cjp.Bind(&p))
+	assert.Equal(t, "sync", p.Name)
+	assert.Equal(t, 20, p.Limit)
+	assert.Equal(t, 5*time.Second, p.Timeout)
+	assert.Equal(t, []string{"x"}, p.Tags)
+	assert.Equal(t, dbParams{Host: "localhost", Port: 3307}, p.DB)
+}
+
+func TestCtxJobParam_Bind_error(t *testing.T) {
+	cjp := &param.CtxJobParam{InputParams: map[string]string{"limit": "abc", "db.port": "x"}}
+
+	var p testParams
+	err := cjp.Bind(&p)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `param "name" is required`)
+	assert.Contains(t, err.Error(), `param "limit": invalid int value "abc"`)
+	assert.Contains(t, err.Error(), `param "db.port": invalid int value "x"`)
+
+	assert.Error(t, cjp.Bind(p))
+}
+
+func TestCtxJobParam_Bind_interface(t *testing.T) {
+	var p struct {
+		Extra  interface{}  `param:"extra"`
+		Reader fmt.Stringer `param:"reader"`
+	}
+
+	cjp := &param.CtxJobParam{InputParam: `{"extra": "abc"}`}
+	assert.NoError(t, cjp.Bind(&p))
+	assert.Equal(t, "abc", p.Extra)
+
+	// not panic on the value is not assignable
+	cjp = &param.CtxJobParam{InputParam: `{"reader": "abc"}`}
+	err := cjp.Bind(&p)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `param "reader": value of type string is not assignable to fmt.Stringer`)
+}
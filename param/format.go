@@ -0,0 +1,147 @@
+package param
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format of the executor params
+type Format string
+
+// the executor param formats
+const (
+	// FormatAuto detect format by the param content
+	FormatAuto Format = ""
+	// FormatKV key=value lines
+	FormatKV   Format = "kv"
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+)
+
+// yaml key line. eg: "name: value", "db:"
+var yamlKeyRegex = regexp.MustCompile(`^[\w.-]+:(\s|$)`)
+
+// DetectFormat of the params string.
+//
+// - JSON: is a JSON object
+// - YAML: start with "---", or the first line is "key: value" and no key=value lines
+// - otherwise is key=value lines
+func DetectFormat(str string) Format {
+	str = strings.TrimSpace(str)
+	if str == "" {
+		return FormatKV
+	}
+
+	if strings.HasPrefix(str, "{") && json.Valid([]byte(str)) {
+		return FormatJSON
+	}
+
+	if strings.HasPrefix(str, "---") {
+		return FormatYAML
+	}
+
+	first := true
+	for _, line := range strings.Split(str, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if first && !yamlKeyRegex.MatchString(line) {
+			return FormatKV
+		}
+		first = false
+
+		// has key=value line
+		if eq := strings.IndexByte(line, '='); eq > 0 && !strings.Contains(line[:eq], ":") {
+			return FormatKV
+		}
+	}
+
+	if first {
+		return FormatKV
+	}
+	return FormatYAML
+}
+
+// Decode the JSON object or YAML document to map
+func Decode(str string, format Format) (data map[string]interface{}, err error) {
+	// the decoder may panic on malformed input, the params are from admin, don't crash the executor.
+	defer func() {
+		if r := recover(); r != nil {
+			data, err = nil, fmt.Errorf("invalid %s params: %v", format, r)
+		}
+	}()
+
+	switch format {
+	case FormatJSON:
+		return decodeJSONObject(str)
+	case FormatYAML:
+		data := make(map[string]interface{})
+		if err := yaml.Unmarshal([]byte(str), &data); err != nil {
+			return nil, fmt.Errorf("invalid YAML document: %s", err.Error())
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("can not decode the %q format params", format)
+}
+
+// Flatten the structured data to key-value map. nested keys are joined by ".".
+//
+// eg: {"db": {"host": "x"}, "tags": ["a", "b"]} => {"db.host": "x", "tags": "a,b", "tags.0": "a", "tags.1": "b"}
+func Flatten(data map[string]interface{}) map[string]string {
+	kvs := make(map[string]string, len(data))
+	flattenTo(kvs, "", data)
+	return kvs
+}
+
+func flattenTo(kvs map[string]string, key string, val interface{}) {
+	switch tv := val.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(tv))
+		for k := range tv {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			flattenTo(kvs, joinKey(key, k), tv[k])
+		}
+	case []interface{}:
+		scalars := make([]string, 0, len(tv))
+		for i, item := range tv {
+			flattenTo(kvs, joinKey(key, fmt.Sprint(i)), item)
+			if isScalar(item) {
+				scalars = append(scalars, fmt.Sprint(item))
+			}
+		}
+
+		if len(scalars) == len(tv) {
+			kvs[key] = strings.Join(scalars, ",")
+		}
+	case nil:
+		kvs[key] = ""
+	default:
+		kvs[key] = fmt.Sprint(tv)
+	}
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func isScalar(val interface{}) bool {
+	switch val.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	return true
+}
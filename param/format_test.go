@@ -0,0 +1,60 @@
+package param_test
+
+import (
+	"testing"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/param"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := map[string]param.Format{
+		"":                              param.FormatKV,
+		"name=inhere\nage=23":           param.FormatKV,
+		"url=http://a.com:80/x":         param.FormatKV,
+		`{"name": "inhere"}`:            param.FormatJSON,
+		`{"name": `:                     param.FormatKV,
+		"---\nname: inhere":             param.FormatYAML,
+		"# comment\nname: inhere\nn: 2": param.FormatYAML,
+		"db:\n  host: localhost":        param.FormatYAML,
+		"name: inhere\nage=23":          param.FormatKV,
+		"some text":                     param.FormatKV,
+	}
+
+	for str, want := range tests {
+		assert.Equal(t, want, param.DetectFormat(str), "params: %q", str)
+	}
+}
+
+func TestDecode_Flatten(t *testing.T) {
+	data, err := param.Decode("name: inhere\ndb:\n  host: localhost\n  port: 3306\ntags: [a, b]\n", param.FormatYAML)
+	assert.NoError(t, err)
+	assert.Equal(t, "inhere", data["name"])
+
+	kvs := param.Flatten(data)
+	assert.Equal(t, "inhere", kvs["name"])
+	assert.Equal(t, "localhost", kvs["db.host"])
+	assert.Equal(t, "3306", kvs["db.port"])
+	assert.Equal(t, "a,b", kvs["tags"])
+	assert.Equal(t, "b", kvs["tags.1"])
+
+	data, err = param.Decode(`{"users": [{"id": 1}], "debug": true}`, param.FormatJSON)
+	assert.NoError(t, err)
+	kvs = param.Flatten(data)
+	assert.Equal(t, "1", kvs["users.0.id"])
+	assert.Equal(t, "true", kvs["debug"])
+	_, ok := kvs["users"]
+	assert.False(t, ok)
+
+	_, err = param.Decode(`{"name": }`, param.FormatJSON)
+	assert.Error(t, err)
+}
+
+func TestDecode_malformed(t *testing.T) {
+	// CVE-2022-28948, panic in old yaml.v3
+	str := "0: [:!00 \xef"
+	assert.Equal(t, param.FormatYAML, param.DetectFormat(str))
+
+	_, err := param.Decode(str, param.FormatYAML)
+	assert.Error(t, err)
+}
@@ -20,6 +20,14 @@ type CtxJobParam struct {
 	// InputParam is full user input param string. equals to InputParams["fullParam"]
 	InputParam  string
 	InputParams map[string]string
+	// Format of the input param. on JSON or YAML, the nested keys in InputParams are joined by "."
+	Format Format
+	// Data the raw structured data on input param is JSON or YAML.
+	Data map[string]interface{}
+	// Values the parsed values on input param is key=value lines. the duplicate keys are collected as array.
+	Values Values
+	// Result the handle result reported by the handler, will be sent to admin on callback.
+	Result *HandleResult
 }
 
 // Param get input param by name.
@@ -37,6 +45,18 @@ func (cjp *CtxJobParam) ParamInt(name string) int {
 	return 0
 }
 
+// ParamValues get all values of the duplicate key. eg: "tag=a\ntag=b" => [a, b]
+func (cjp *CtxJobParam) ParamValues(name string) []string {
+	if cjp.Values != nil {
+		return cjp.Values.All(name)
+	}
+
+	if val, ok := cjp.InputParams[name]; ok {
+		return []string{val}
+	}
+	return nil
+}
+
 // TryParam try to get input param by name.
 func (cjp *CtxJobParam) TryParam(name string) (string, bool) {
 	val, ok := cjp.InputParams[name]
@@ -0,0 +1,345 @@
+package param
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Values the parsed key=value params. the duplicate keys will collect as array.
+type Values map[string][]string
+
+// Get the first value by key
+func (vs Values) Get(key string) string {
+	if ss := vs[key]; len(ss) > 0 {
+		return ss[0]
+	}
+	return ""
+}
+
+// All values by key
+func (vs Values) All(key string) []string {
+	return vs[key]
+}
+
+// Add value to key
+func (vs Values) Add(key, val string) {
+	vs[key] = append(vs[key], val)
+}
+
+// ToMap convert to key-value map, the multi values will be joined by ",".
+func (vs Values) ToMap() map[string]string {
+	mp := make(map[string]string, len(vs))
+	for key, ss := range vs {
+		mp[key] = strings.Join(ss, ",")
+	}
+	return mp
+}
+
+// LookupEnv func for interpolate the ${VAR} in values. default is os.LookupEnv
+var LookupEnv = os.LookupEnv
+
+// ParseKV parse the key=value lines.
+//
+// syntax:
+//
+//	# comment line, blank lines are ignored
+//	name = inhere            # inline comment, need space before '#'
+//	verbose                  # key without '=', value is empty
+//	title = "hello \"world\"\n"  # double quoted, support escape chars
+//	raw = 'not ${EXPANDED}'  # single quoted, the raw value
+//	sql = "select *
+//	  from users"            # quoted value can be multi-line
+//	list = a, b, \
+//	  c                      # line end with '\' will continue to next line
+//	tag = a                  # duplicate keys collect as array: tag => [a, b]
+//	tag = b
+//	home = ${HOME}/app       # env var interpolation, with default: ${APP_ENV:-dev}. use '$${' for a literal '${'
+func ParseKV(str string) (Values, error) {
+	p := &lineParser{src: str, line: 1}
+	vs := make(Values)
+
+	for p.nextLine() {
+		key, hasEq := p.readKey()
+		if key == "" {
+			return nil, p.errorf("missing the param key")
+		}
+
+		var val string
+		if hasEq {
+			var err error
+			if val, err = p.readValue(); err != nil {
+				return nil, err
+			}
+		}
+
+		vs.Add(key, val)
+	}
+	return vs, nil
+}
+
+// ParseLines parse the params to lines. comments and blank lines are ignored,
+// each line value is parsed same as the value of ParseKV(). script jobs use it as the cmd args.
+func ParseLines(str string) ([]string, error) {
+	p := &lineParser{src: str, line: 1}
+
+	var lines []string
+	for p.nextLine() {
+		val, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, val)
+	}
+	return lines, nil
+}
+
+// lineParser parse the params by char
+type lineParser struct {
+	src string
+	pos int
+	// line number, use for error message
+	line int
+}
+
+func (p *lineParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("parse params error at line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *lineParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *lineParser) peek() byte {
+	return p.src[p.pos]
+}
+
+func (p *lineParser) hasPrefix(s string) bool {
+	return strings.HasPrefix(p.src[p.pos:], s)
+}
+
+func (p *lineParser) skipSpaces() {
+	for !p.eof() && isSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+// skipToEOL skip the remaining chars of current line, not include the '\n'
+func (p *lineParser) skipToEOL() {
+	for !p.eof() && p.peek() != '\n' {
+		p.pos++
+	}
+}
+
+// nextLine skip blank and comment lines, return false on EOF.
+func (p *lineParser) nextLine() bool {
+	for !p.eof() {
+		p.skipSpaces()
+		if p.eof() {
+			return false
+		}
+
+		switch p.peek() {
+		case '\n':
+			p.pos++
+			p.line++
+		case '#':
+			p.skipToEOL()
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// readKey read key before the '='. the line without '=' is a key with empty value.
+func (p *lineParser) readKey() (key string, hasEq bool) {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if c == '=' {
+			key = strings.TrimSpace(p.src[start:p.pos])
+			p.pos++
+			return key, true
+		}
+
+		if c == '\n' || c == '#' && p.pos > start && isSpace(p.src[p.pos-1]) {
+			break
+		}
+		p.pos++
+	}
+
+	key = strings.TrimSpace(p.src[start:p.pos])
+	p.skipToEOL()
+	return key, false
+}
+
+// readValue read value to the end of line. quoted value can be multi-line.
+func (p *lineParser) readValue() (string, error) {
+	p.skipSpaces()
+	if p.eof() {
+		return "", nil
+	}
+
+	var (
+		val string
+		err error
+	)
+
+	switch p.peek() {
+	case '"':
+		val, err = p.readDoubleQuoted()
+	case '\'':
+		val, err = p.readSingleQuoted()
+	default:
+		return p.readUnquoted()
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	// only allow spaces and comment after the quoted value
+	p.skipSpaces()
+	if !p.eof() && p.peek() != '\n' {
+		if p.peek() != '#' {
+			return "", p.errorf("unexpected chars after the quoted value")
+		}
+		p.skipToEOL()
+	}
+	return val, nil
+}
+
+func (p *lineParser) readDoubleQuoted() (string, error) {
+	startLine := p.line
+	p.pos++ // skip '"'
+
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case c == '"':
+			p.pos++
+			return sb.String(), nil
+		case c == '\\' && p.pos+1 < len(p.src):
+			p.pos += 2
+			switch e := p.src[p.pos-1]; e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case '"', '\\', '$':
+				sb.WriteByte(e)
+			case '\n': // line continuation
+				p.line++
+			default:
+				sb.WriteByte('\\')
+				sb.WriteByte(e)
+			}
+		case c == '$' && p.hasPrefix("${"):
+			if err := p.interpolate(&sb); err != nil {
+				return "", err
+			}
+		default:
+			if c == '\n' {
+				p.line++
+			}
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	p.line = startLine
+	return "", p.errorf("the double quoted value is not closed")
+}
+
+func (p *lineParser) readSingleQuoted() (string, error) {
+	startLine := p.line
+	p.pos++ // skip '\''
+
+	end := strings.IndexByte(p.src[p.pos:], '\'')
+	if end < 0 {
+		return "", p.errorf("the single quoted value is not closed")
+	}
+
+	val := p.src[p.pos : p.pos+end]
+	p.line = startLine + strings.Count(val, "\n")
+	p.pos += end + 1
+	return val, nil
+}
+
+func (p *lineParser) readUnquoted() (string, error) {
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case c == '\n':
+			return strings.TrimSpace(sb.String()), nil
+		case c == '#' && sb.Len() > 0 && isSpace(p.src[p.pos-1]):
+			// inline comment
+			p.skipToEOL()
+			return strings.TrimSpace(sb.String()), nil
+		case c == '\\' && p.isContinuation():
+			// line continuation, join with next line.
+			p.skipToEOL()
+			p.pos++
+			p.line++
+			p.skipSpaces()
+		case c == '$' && p.hasPrefix("$${"):
+			sb.WriteString("${")
+			p.pos += 3
+		case c == '$' && p.hasPrefix("${"):
+			if err := p.interpolate(&sb); err != nil {
+				return "", err
+			}
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// isContinuation check the '\' at pos is the last non-space char of line, and has next line.
+func (p *lineParser) isContinuation() bool {
+	i := p.pos + 1
+	for i < len(p.src) && isSpace(p.src[i]) {
+		i++
+	}
+	return i < len(p.src) && p.src[i] == '\n'
+}
+
+// interpolate the ${VAR} or ${VAR:-default} at pos
+func (p *lineParser) interpolate(sb *strings.Builder) error {
+	end := strings.IndexByte(p.src[p.pos:], '}')
+	if end < 0 || strings.ContainsRune(p.src[p.pos:p.pos+end], '\n') {
+		return p.errorf("the env var reference '${' is not closed")
+	}
+
+	expr := p.src[p.pos+2 : p.pos+end]
+	p.pos += end + 1
+
+	name, def := expr, ""
+	hasDef := false
+	if i := strings.Index(expr, ":-"); i >= 0 {
+		name, def, hasDef = expr[:i], expr[i+2:], true
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return p.errorf("the env var name is empty")
+	}
+
+	val, ok := LookupEnv(name)
+	if (!ok || val == "") && hasDef {
+		val = def
+	}
+	sb.WriteString(val)
+	return nil
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r'
+}
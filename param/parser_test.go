@@ -0,0 +1,101 @@
+package param_test
+
+import (
+	"testing"
+
+	"github.com/goft-cloud/go-xxl-job-client/v2/param"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKV(t *testing.T) {
+	lookup := param.LookupEnv
+	param.LookupEnv = func(name string) (string, bool) {
+		if name == "APP_HOME" {
+			return "/opt/app", true
+		}
+		return "", false
+	}
+	defer func() { param.LookupEnv = lookup }()
+
+	vs, err := param.ParseKV(`
+# comment line
+name = inhere   # inline comment
+url=http://a.com/x#frag
+color = #fff
+verbose
+title = "hello \"world\"\n"  # quoted
+raw = 'not ${APP_HOME}'
+sql = "select *
+  from users"
+list = a, b, \
+  c
+tag = a
+tag = b
+home = ${APP_HOME}/data
+env = ${APP_ENV:-dev}
+lit = $${APP_HOME}
+empty =
+`)
+	assert.NoError(t, err)
+	assert.Equal(t, "inhere", vs.Get("name"))
+	assert.Equal(t, "http://a.com/x#frag", vs.Get("url"))
+	assert.Equal(t, "#fff", vs.Get("color"))
+	assert.Equal(t, []string{""}, vs.All("verbose"))
+	assert.Equal(t, "hello \"world\"\n", vs.Get("title"))
+	assert.Equal(t, "not ${APP_HOME}", vs.Get("raw"))
+	assert.Equal(t, "select *\n  from users", vs.Get("sql"))
+	assert.Equal(t, "a, b, c", vs.Get("list"))
+	assert.Equal(t, []string{"a", "b"}, vs.All("tag"))
+	assert.Equal(t, "/opt/app/data", vs.Get("home"))
+	assert.Equal(t, "dev", vs.Get("env"))
+	assert.Equal(t, "${APP_HOME}", vs.Get("lit"))
+	assert.Equal(t, "", vs.Get("empty"))
+
+	mp := vs.ToMap()
+	assert.Equal(t, "a,b", mp["tag"])
+	assert.Len(t, mp, 13)
+}
+
+func TestParseKV_error(t *testing.T) {
+	tests := map[string]string{
+		"a = 1\n= 2":         "line 2: missing the param key",
+		"a = \"not closed\n": "line 1: the double quoted value is not closed",
+		"a = 'not closed":    "line 1: the single quoted value is not closed",
+		"a = \"v\" tail":     "line 1: unexpected chars after the quoted value",
+		"a = ${HOME":         "line 1: the env var reference '${' is not closed",
+		"\n\na = ${:-def}":   "line 3: the env var name is empty",
+	}
+
+	for str, want := range tests {
+		_, err := param.ParseKV(str)
+		if assert.Error(t, err, "params: %q", str) {
+			assert.Contains(t, err.Error(), want)
+		}
+	}
+}
+
+func TestParseLines(t *testing.T) {
+	lines, err := param.ParseLines("# args\n\n--name=inhere\n\"hello world\"\n  -v  \n")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--name=inhere", "hello world", "-v"}, lines)
+
+	lines, err = param.ParseLines("")
+	assert.NoError(t, err)
+	assert.Empty(t, lines)
+}
+
+func TestCtxJobParam_Bind_values(t *testing.T) {
+	vs, err := param.ParseKV("name=inhere\ntags=a\ntags=b")
+	assert.NoError(t, err)
+
+	cjp := &param.CtxJobParam{InputParams: vs.ToMap(), Values: vs}
+	assert.Equal(t, []string{"a", "b"}, cjp.ParamValues("tags"))
+
+	var p struct {
+		Name string
+		Tags []string
+	}
+	assert.NoError(t, cjp.Bind(&p))
+	assert.Equal(t, "inhere", p.Name)
+	assert.Equal(t, []string{"a", "b"}, p.Tags)
+}
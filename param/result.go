@@ -0,0 +1,30 @@
+package param
+
+import "sync"
+
+// HandleResult the task handle result reported by the job handler.
+// like the XxlJobHelper.handleSuccess(), handleFail() in java.
+type HandleResult struct {
+	mu   sync.Mutex
+	code int32
+	msg  string
+	set  bool
+}
+
+// Set the result code and message, the last one will be used.
+func (r *HandleResult) Set(code int32, msg string) {
+	r.mu.Lock()
+	r.code, r.msg, r.set = code, msg, true
+	r.mu.Unlock()
+}
+
+// Get the result, ok is false on not set.
+func (r *HandleResult) Get() (code int32, msg string, ok bool) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.code, r.msg, r.set
+}
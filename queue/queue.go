@@ -54,8 +54,11 @@ func (q *Queue) Put(item interface{}) error {
 	return nil
 }
 
-// Poll 保证单协程执行，不加锁
+// Poll an item from queue head
 func (q *Queue) Poll() (has bool, item interface{}) {
+	q.Lock()
+	defer q.Unlock()
+
 	node := q.Head.Next
 	if node == nil {
 		return false, nil
@@ -69,8 +72,25 @@ func (q *Queue) Poll() (has bool, item interface{}) {
 	return true, res
 }
 
-func (q *Queue) Clear() {
-	q = NewQueue()
+// Clear all items and return them.
+func (q *Queue) Clear() (items []interface{}) {
+	q.Lock()
+	defer q.Unlock()
+
+	for node := q.Head.Next; node != nil; node = node.Next {
+		items = append(items, node.Item)
+	}
+
+	node := &Node{}
+	q.Head = node
+	q.Last = node
+	atomic.StoreInt32(&q.Count, 0)
+	return
+}
+
+// Len get the waiting items number
+func (q *Queue) Len() int {
+	return int(atomic.LoadInt32(&q.Count))
 }
 
 // HasNext check
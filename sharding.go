@@ -0,0 +1,117 @@
+package xxl
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+)
+
+// allowed column name for ShardSQL. eg: id, t.user_id
+var sqlColumnRegex = regexp.MustCompile(`^[A-Za-z_][\w.]*$`)
+
+// InShard check the integer id belongs to the current shard. always true on is not a broadcast job.
+//
+// Usage:
+//
+//	for _, order := range orders {
+//		if !xxl.InShard(ctx, order.ID) {
+//			continue
+//		}
+//		// handle the order ...
+//	}
+func InShard(ctx context.Context, id int64) bool {
+	idx, total := GetSharding(ctx)
+	if total <= 1 {
+		return true
+	}
+	return ShardOf(id, total) == idx
+}
+
+// InShardKey check the string key belongs to the current shard, by the stable hash. see ShardOfKey()
+func InShardKey(ctx context.Context, key string) bool {
+	idx, total := GetSharding(ctx)
+	if total <= 1 {
+		return true
+	}
+	return ShardOfKey(key, total) == idx
+}
+
+// ShardOf get the shard index of the integer id. the negative id is also mapped to [0, total)
+func ShardOf(id int64, total int32) int32 {
+	if total <= 1 {
+		return 0
+	}
+
+	t := int64(total)
+	return int32((id%t + t) % t)
+}
+
+// ShardOfKey get the shard index of the string key, by FNV-1a 32 hash.
+// the result is stable across processes and versions.
+func ShardOfKey(key string, total int32) int32 {
+	if total <= 1 {
+		return 0
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int32(h.Sum32() % uint32(total))
+}
+
+// ShardRange split the [0, n) to the shards, return the [start, end) of the current shard.
+// the first n%total shards will get one more item. return [0, n) on is not a broadcast job.
+//
+// Usage:
+//
+//	start, end := xxl.ShardRange(ctx, 1000)
+//	for i := start; i < end; i++ {
+//		// ...
+//	}
+func ShardRange(ctx context.Context, n int64) (start, end int64) {
+	idx, total := GetSharding(ctx)
+	return SplitRange(n, idx, total)
+}
+
+// SplitRange split the [0, n) to total parts, return the [start, end) of the idx part.
+func SplitRange(n int64, idx, total int32) (start, end int64) {
+	if total <= 1 || n <= 0 {
+		if n < 0 {
+			n = 0
+		}
+		return 0, n
+	}
+
+	if idx < 0 || idx >= total {
+		return 0, 0
+	}
+
+	size, rem := n/int64(total), n%int64(total)
+	i := int64(idx)
+
+	start = i * size
+	if i < rem {
+		start += i
+		size++
+	} else {
+		start += rem
+	}
+	return start, start + size
+}
+
+// ShardSQL build the SQL condition for select the rows of the current shard by the integer column.
+// eg: "MOD(id, 3) = 1", return "1 = 1" on is not a broadcast job.
+//
+// NOTICE: the column only allow letters, digits, '_' and '.', otherwise will panic.
+// the negative values should not be used, the MOD() sign is different on databases.
+func ShardSQL(ctx context.Context, column string) string {
+	if !sqlColumnRegex.MatchString(column) {
+		panic("xxl: invalid column name for shard SQL: " + column)
+	}
+
+	idx, total := GetSharding(ctx)
+	if total <= 1 {
+		return "1 = 1"
+	}
+	return fmt.Sprintf("MOD(%s, %d) = %d", column, total, idx)
+}
@@ -0,0 +1,64 @@
+package xxl_test
+
+import (
+	"context"
+	"testing"
+
+	xxl "github.com/goft-cloud/go-xxl-job-client/v2"
+	"github.com/goft-cloud/go-xxl-job-client/v2/param"
+	"github.com/stretchr/testify/assert"
+)
+
+func shardCtx(idx, total int32) context.Context {
+	cjp := &param.CtxJobParam{ShardIndex: idx, ShardTotal: total}
+	return context.WithValue(context.Background(), xxl.CtxParamKey, cjp)
+}
+
+func TestInShard(t *testing.T) {
+	ctx := shardCtx(1, 3)
+	assert.True(t, xxl.InShard(ctx, 4))
+	assert.False(t, xxl.InShard(ctx, 5))
+	assert.Equal(t, int32(2), xxl.ShardOf(-1, 3))
+
+	// stable hash
+	assert.Equal(t, xxl.ShardOfKey("user-100", 3), xxl.ShardOfKey("user-100", 3))
+	assert.Equal(t, xxl.ShardOfKey("user-100", 3) == 1, xxl.InShardKey(ctx, "user-100"))
+
+	// each key belongs to one shard
+	for _, key := range []string{"a", "b", "c", "d"} {
+		n := 0
+		for i := int32(0); i < 3; i++ {
+			if xxl.InShardKey(shardCtx(i, 3), key) {
+				n++
+			}
+		}
+		assert.Equal(t, 1, n)
+	}
+
+	// not a broadcast job
+	assert.True(t, xxl.InShard(context.Background(), 5))
+	assert.True(t, xxl.InShardKey(shardCtx(0, 0), "a"))
+}
+
+func TestShardRange(t *testing.T) {
+	var ranges [][2]int64
+	for i := int32(0); i < 3; i++ {
+		start, end := xxl.ShardRange(shardCtx(i, 3), 10)
+		ranges = append(ranges, [2]int64{start, end})
+	}
+	assert.Equal(t, [][2]int64{{0, 4}, {4, 7}, {7, 10}}, ranges)
+
+	start, end := xxl.SplitRange(2, 2, 3)
+	assert.Equal(t, start, end)
+
+	start, end = xxl.ShardRange(context.Background(), 10)
+	assert.Equal(t, [2]int64{0, 10}, [2]int64{start, end})
+}
+
+func TestShardSQL(t *testing.T) {
+	assert.Equal(t, "MOD(t.id, 3) = 1", xxl.ShardSQL(shardCtx(1, 3), "t.id"))
+	assert.Equal(t, "1 = 1", xxl.ShardSQL(context.Background(), "id"))
+	assert.Panics(t, func() {
+		xxl.ShardSQL(shardCtx(1, 3), "id; drop table users")
+	})
+}
@@ -0,0 +1,126 @@
+// Package tracing provide the tracing hooks around job execution.
+//
+// default tracer is noop. implement the Tracer(eg: adapt the OpenTelemetry tracer) and set it by SetTracer.
+package tracing
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// the span names
+const (
+	// SpanRun span for a run request, from received the trigger to callback the result.
+	SpanRun = "xxl-job.run"
+	// SpanBeanExecute span for execute the bean job handler
+	SpanBeanExecute = "xxl-job.bean.execute"
+)
+
+// the span attribute keys
+const (
+	AttrJobId      = "xxl.job_id"
+	AttrLogId      = "xxl.log_id"
+	AttrHandler    = "xxl.handler"
+	AttrShardIndex = "xxl.shard_index"
+	AttrShardTotal = "xxl.shard_total"
+	// AttrResult the task result code. eg: 200 success, 500 fail, 502 timeout
+	AttrResult = "xxl.result"
+)
+
+// Attr span attribute
+type Attr struct {
+	Key   string
+	Value interface{}
+}
+
+// KV create an Attr
+func KV(key string, val interface{}) Attr {
+	return Attr{Key: key, Value: val}
+}
+
+// Span interface, is compatible with the OpenTelemetry trace.Span methods.
+type Span interface {
+	// SetAttributes add attributes to span
+	SetAttributes(attrs ...Attr)
+	// RecordError record the error and mark span status is error.
+	RecordError(err error)
+	// End the span
+	End()
+}
+
+// Tracer interface
+type Tracer interface {
+	// Start a span. the returned ctx should carry the span for create child spans.
+	Start(ctx context.Context, name string, attrs ...Attr) (context.Context, Span)
+}
+
+// TracerFunc the user-supplied tracer callback, implement the Tracer
+type TracerFunc func(ctx context.Context, name string, attrs ...Attr) (context.Context, Span)
+
+// Start a span
+func (fn TracerFunc) Start(ctx context.Context, name string, attrs ...Attr) (context.Context, Span) {
+	return fn(ctx, name, attrs...)
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attr) {}
+func (noopSpan) RecordError(error)     {}
+func (noopSpan) End()                  {}
+
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string, _ ...Attr) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+// holder for atomic store the Tracer
+type holder struct {
+	Tracer
+}
+
+var current atomic.Value
+
+func init() {
+	current.Store(holder{noopTracer{}})
+}
+
+// SetTracer set the tracer, reset to noop on t is nil
+func SetTracer(t Tracer) {
+	if t == nil {
+		t = noopTracer{}
+	}
+	current.Store(holder{t})
+}
+
+// GetTracer get current tracer
+func GetTracer() Tracer {
+	return current.Load().(holder).Tracer
+}
+
+type spanCtxKey struct{}
+
+// StartSpan start a span by current tracer, the span can be got by SpanFromContext(ctx).
+//
+// handlers can use it for create the child spans.
+func StartSpan(ctx context.Context, name string, attrs ...Attr) (context.Context, Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ctx, span := GetTracer().Start(ctx, name, attrs...)
+	if span == nil {
+		span = noopSpan{}
+	}
+	return context.WithValue(ctx, spanCtxKey{}, span), span
+}
+
+// SpanFromContext get the current span. return a noop span on not found.
+func SpanFromContext(ctx context.Context) Span {
+	if ctx != nil {
+		if span, ok := ctx.Value(spanCtxKey{}).(Span); ok {
+			return span
+		}
+	}
+	return noopSpan{}
+}
@@ -5,6 +5,7 @@ import (
 
 	getty "github.com/apache/dubbo-getty"
 	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+	"github.com/goft-cloud/go-xxl-job-client/v2/metrics"
 )
 
 // GettyRPCClient struct
@@ -25,6 +26,7 @@ func (c *GettyRPCClient) AddSession(session getty.Session) {
 	}
 
 	c.sessions = append(c.sessions, session)
+	metrics.Set(metrics.ActiveSessions, nil, float64(len(c.sessions)))
 }
 
 // RemoveSession from sessions
@@ -42,10 +44,11 @@ func (c *GettyRPCClient) RemoveSession(session getty.Session) {
 	for i, s := range c.sessions {
 		if s == session {
 			c.sessions = append(c.sessions[:i], c.sessions[i+1:]...)
-			logger.Infof("remove session{%s}, its index{%d}", session.Stat(), i)
+			logger.Info("remove session", "session", session.Stat(), "index", i)
 			break
 		}
 	}
 
-	logger.Infof("after remove session{%s}, left number:%d", session.Stat(), len(c.sessions))
+	metrics.Set(metrics.ActiveSessions, nil, float64(len(c.sessions)))
+	logger.Info("after remove session", "session", session.Stat(), "left", len(c.sessions))
 }
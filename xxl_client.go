@@ -1,6 +1,9 @@
 package xxl
 
 import (
+	"context"
+	gohttp "net/http"
+
 	getty "github.com/apache/dubbo-getty"
 	"github.com/apache/dubbo-go-hessian2"
 	"github.com/goft-cloud/go-xxl-job-client/v2/admin"
@@ -10,7 +13,10 @@ import (
 	"github.com/goft-cloud/go-xxl-job-client/v2/handler/http"
 	"github.com/goft-cloud/go-xxl-job-client/v2/handler/rpc"
 	"github.com/goft-cloud/go-xxl-job-client/v2/logger"
+	"github.com/goft-cloud/go-xxl-job-client/v2/metrics"
 	"github.com/goft-cloud/go-xxl-job-client/v2/option"
+	"github.com/goft-cloud/go-xxl-job-client/v2/param"
+	"github.com/goft-cloud/go-xxl-job-client/v2/tracing"
 	"github.com/goft-cloud/go-xxl-job-client/v2/transport"
 	"github.com/gookit/goutil"
 )
@@ -19,6 +25,12 @@ import (
 type XxlClient struct {
 	options  option.ClientOptions
 	executor *executor2.Executor
+	// admin server api
+	adminServer *admin.XxlAdminServer
+	// logCleaner clean expired job logs
+	logCleaner *logger.LogCleaner
+	// metricsServer serve the metrics on side port
+	metricsServer *gohttp.Server
 	// request handler
 	requestHandler *handler.RequestProcess
 }
@@ -40,8 +52,22 @@ func NewXxlClient(opts ...option.OptionFunc) *XxlClient {
 		executor,
 	)
 
-	// set log path.
+	// set log path and custom logger.
 	logger.SetLogBasePath(clientOps.LogBasePath)
+	if clientOps.Logger != nil {
+		logger.SetLogger(clientOps.Logger)
+	}
+
+	// enable metrics
+	if clientOps.Metrics == nil && clientOps.MetricsAddr != "" {
+		clientOps.Metrics = metrics.NewRegistry()
+	}
+	if clientOps.Metrics != nil {
+		metrics.SetRecorder(clientOps.Metrics)
+	}
+	if clientOps.Tracer != nil {
+		tracing.SetTracer(clientOps.Tracer)
+	}
 
 	adminServer.AccessToken = map[string]string{
 		"XXL-JOB-ACCESS-TOKEN": clientOps.AccessToken,
@@ -80,8 +106,9 @@ func NewXxlClient(opts ...option.OptionFunc) *XxlClient {
 	return &XxlClient{
 		requestHandler: requestHandler,
 		// other
-		executor: executor,
-		options:  clientOps,
+		executor:    executor,
+		options:     clientOps,
+		adminServer: adminServer,
 	}
 }
 
@@ -97,44 +124,216 @@ func (c *XxlClient) MustRun() {
 	}
 }
 
-// Run start and run client.
+// Run start the client and block until receive the OS close signals,
+// then shutdown the client gracefully.
 func (c *XxlClient) Run() error {
-	logger.Infof("go executor client run on mode: %s", option.RunMode())
-	logger.Infof("the xxl-job admin address list: %v", c.options.AdminAddr)
-	logger.Debugf("the go executor name is: %s, enableHttp: %v", c.executor.AppName, c.options.EnableHttp)
+	if err := c.Start(); err != nil {
+		return err
+	}
 
-	// register to xxl-job admin
-	if c.options.Enable {
-		c.requestHandler.RegisterExecutor()
+	executor2.WaitCloseSignals(func() {
+		logger.Info("client server closing ......")
+	})
 
-		// remove executor on client server close
-		c.executor.GetClient().ServeCloserFn = c.requestHandler.UnregisterExecutor
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.options.ShutdownTimeout)
+	defer cancel()
+
+	return c.Shutdown(ctx)
+}
+
+// Start the client server and register to xxl-job admin.
+//
+// it does not block and does not listen the OS signals, use Shutdown() for stop it.
+func (c *XxlClient) Start() error {
+	logger.Info("go executor client run", "mode", option.RunMode(), "adminAddr", c.options.AdminAddr)
+	logger.Debug("go executor client info", "appName", c.executor.AppName, "enableHttp", c.options.EnableHttp)
 
-	// custom set shell bin
+	// custom set shell bin, before the glue types. the GLUE_SHELL added by WithGlueType will override it.
 	if c.options.ShellBin != "" {
 		handler.SetShellBin(c.options.ShellBin)
 	}
 
+	for _, gt := range c.options.GlueTypes {
+		handler.RegisterGlueType(gt)
+	}
+
+	// check the script interpreters exist, the jobs of these glue types will fail.
+	for name, err := range handler.CheckGlueTypes() {
+		logger.Warn("the script interpreter not found", "glueType", name, "error", err.Error())
+	}
+	handler.ConfigScript(func(opts *handler.ScriptOptions) {
+		opts.Env = c.options.ScriptEnv
+		opts.DisableShardArgs = c.options.DisableShardArgs
+		opts.KillGracePeriod = c.options.ScriptKillGrace
+		opts.Limits = c.options.ScriptLimits
+		opts.GlueLimits = c.options.GlueLimits
+		opts.WorkDir = c.options.ScriptWorkDir
+		opts.Credential = c.options.ScriptCredential
+		opts.MaxLogSize = c.options.ScriptMaxLogSize
+	})
+
 	err := logger.InitLogPath()
 	if err != nil {
 		return err
 	}
 
-	logger.Infof("go executor client started on port: %d", c.options.ClientPort)
+	c.startLogCleaner()
+	c.startMetricsServer()
+	c.adminServer.StartCallback()
+	c.executor.Start(c.requestHandler.JobManager.BeanJobLength() + 1)
+	c.requestHandler.JobManager.StartIdleEvict(c.options.JobIdleCycles, c.options.JobIdleInterval)
+
+	// register to xxl-job admin
+	if c.options.Enable {
+		if err := c.requestHandler.RegisterExecutor(); err != nil {
+			c.stopStarted()
+			return err
+		}
+	}
+
+	logger.Info("go executor client started", "port", c.options.ClientPort)
 	if !c.options.Enable {
-		logger.Infof("NOTICE: xxl-job go executor is DISABLED(by options.Enable=false)")
+		logger.Warn("NOTICE: xxl-job go executor is DISABLED(by options.Enable=false)")
 	}
+	return nil
+}
 
-	c.executor.Run(c.requestHandler.JobManager.BeanJobLength() + 1)
+// Shutdown the client gracefully.
+//
+// - stop accept new triggers and unregister from xxl-job admin
+// - wait the running tasks completed and callback to admin, kill them if ctx is done before that
+// - flush the buffered callbacks, persist them to file on send failed
+// - close the client server
+func (c *XxlClient) Shutdown(ctx context.Context) error {
+	err := c.requestHandler.Shutdown(ctx)
+	if cbErr := c.stopCallback(); err == nil {
+		err = cbErr
+	}
+	c.executor.Close()
 
-	return nil
+	if c.logCleaner != nil {
+		c.logCleaner.Stop()
+	}
+	if c.metricsServer != nil {
+		_ = c.metricsServer.Shutdown(ctx)
+	}
+
+	logger.Info("go executor client is shutdown")
+	return err
+}
+
+// stopStarted stop the started servers and goroutines on Start failed.
+func (c *XxlClient) stopStarted() {
+	c.requestHandler.JobManager.StopIdleEvict()
+	c.executor.Close()
+
+	if err := c.stopCallback(); err != nil {
+		logger.Error("stop the callback queue failed", "error", err)
+	}
+
+	if c.logCleaner != nil {
+		c.logCleaner.Stop()
+	}
+	if c.metricsServer != nil {
+		_ = c.metricsServer.Close()
+	}
+}
+
+// stopCallback flush the buffered callbacks with own timeout,
+// the shutdown ctx may be done by waiting the tasks, the callbacks of killed tasks must be flushed.
+func (c *XxlClient) stopCallback() error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.options.Timeout)
+	defer cancel()
+
+	return c.adminServer.StopCallback(ctx)
+}
+
+// startLogCleaner start clean the expired job logs, if has config the retention.
+func (c *XxlClient) startLogCleaner() {
+	if c.options.LogRetentionDays <= 0 && c.options.LogMaxTotalSize <= 0 {
+		return
+	}
+
+	jm := c.requestHandler.JobManager
+	c.logCleaner = logger.NewLogCleaner(c.options.LogRetentionDays, c.options.LogMaxTotalSize, c.options.LogCleanInterval)
+	c.logCleaner.LogInUse = jm.IsTaskRunning
+	c.logCleaner.RemoveGlue = jm.RemoveStaleGlue
+
+	logger.Info("start job logs cleaner", "retentionDays", c.options.LogRetentionDays, "maxTotalSize", c.options.LogMaxTotalSize)
+	c.logCleaner.Start()
+}
+
+// startMetricsServer serve the metrics on side port, if has config the MetricsAddr.
+func (c *XxlClient) startMetricsServer() {
+	if c.options.MetricsAddr == "" {
+		return
+	}
+
+	h, ok := c.options.Metrics.(gohttp.Handler)
+	if !ok {
+		logger.Warn("the metrics recorder is not a http.Handler, skip serve metrics", "addr", c.options.MetricsAddr)
+		return
+	}
+
+	logger.Info("start metrics server", "addr", c.options.MetricsAddr)
+	c.metricsServer = metrics.Serve(c.options.MetricsAddr, h)
+}
+
+// RegisterJob add job handler, can with the job middlewares.
+func (c *XxlClient) RegisterJob(jobName string, function handler.BeanJobRunFunc, mws ...handler.BeanJobMiddleware) {
+	logger.Debug("register bean job handler", "jobName", jobName)
+	c.requestHandler.RegisterJob(jobName, function, mws...)
+}
+
+// RegisterRunner add job runner, can with the job middlewares.
+// if the runner implements handler.BeanJobLifecycle, the Init and Destroy hooks will be called per jobId.
+//
+// Usage:
+//
+//	type MyJob struct{ db *sql.DB }
+//	func (j *MyJob) Init(ctx context.Context) error { /* open db */ }
+//	func (j *MyJob) Destroy(ctx context.Context) error { return j.db.Close() }
+//	func (j *MyJob) Handle(ctx context.Context) error { /* ... */ }
+//
+//	client.RegisterRunner("my_job", &MyJob{})
+func (c *XxlClient) RegisterRunner(jobName string, runner handler.BeanJobRunner, mws ...handler.BeanJobMiddleware) {
+	logger.Debug("register bean job runner", "jobName", jobName)
+	c.requestHandler.RegisterRunner(jobName, runner, mws...)
+}
+
+// SetJobParamFormat set the executor params format hint of the registered job.
+//
+// Usage:
+//
+//	client.RegisterJob("my_job", fn)
+//	client.SetJobParamFormat("my_job", param.FormatJSON)
+func (c *XxlClient) SetJobParamFormat(jobName string, format param.Format) {
+	c.requestHandler.JobManager.SetParamFormat(jobName, format)
+}
+
+// Use add global middlewares for all bean jobs. them run before the job middlewares.
+//
+// Usage:
+//
+//	client.Use(handler.RecoverMiddleware(), handler.SlowJobMiddleware(time.Minute))
+func (c *XxlClient) Use(mws ...handler.BeanJobMiddleware) {
+	c.requestHandler.JobManager.Use(mws...)
+}
+
+// GlueScripts list the cached glue script files
+func (c *XxlClient) GlueScripts() ([]logger.GlueFile, error) {
+	return handler.ListGlueScripts()
+}
+
+// PurgeGlueScripts remove the cached glue scripts of the jobs, will remove all on jobIds is empty.
+// the jobs that have running task will be skipped.
+func (c *XxlClient) PurgeGlueScripts(jobIds ...int32) (int, error) {
+	return c.requestHandler.JobManager.PurgeGlueScripts(jobIds...)
 }
 
-// RegisterJob add job handler.
-func (c *XxlClient) RegisterJob(jobName string, function handler.BeanJobRunFunc) {
-	logger.Debugf("register bean job handler: %s", jobName)
-	c.requestHandler.RegisterJob(jobName, function)
+// LiveJobRunners the number of live job runner goroutines
+func (c *XxlClient) LiveJobRunners() int {
+	return c.requestHandler.JobManager.LiveRunners()
 }
 
 // SetGettyLogger set logger to getty.
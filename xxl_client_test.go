@@ -0,0 +1,152 @@
+package xxl_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	xxl "github.com/goft-cloud/go-xxl-job-client/v2"
+	"github.com/goft-cloud/go-xxl-job-client/v2/handler"
+	"github.com/goft-cloud/go-xxl-job-client/v2/option"
+	"github.com/goft-cloud/go-xxl-job-client/v2/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+// freePort get a free tcp port
+func freePort(t *testing.T) int {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+	assert.NoError(t, ln.Close())
+	return port
+}
+
+func TestXxlClient_Start_registerFailed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xxl-client-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	port := freePort(t)
+
+	client := xxl.NewXxlClient(
+		option.WithAppName("test-app"),
+		option.WithEnableHttp(true),
+		option.WithClientPort(port),
+		option.WithAdminAddress("http://127.0.0.1:1/xxl-job-admin"),
+		option.WithAdminTimeout(time.Second),
+		option.WithLogBasePath(dir),
+	)
+
+	assert.Error(t, client.Start())
+
+	// the client server is closed
+	assert.Eventually(t, func() bool {
+		ln, err := net.Listen("tcp", net.JoinHostPort("", strconv.Itoa(port)))
+		if err != nil {
+			return false
+		}
+		_ = ln.Close()
+		return true
+	}, 3*time.Second, 50*time.Millisecond)
+}
+
+func TestXxlClient_Shutdown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xxl-client-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// fake xxl-job admin, record the job callbacks. the callback api is slow.
+	callbacks := make(chan *transport.HandleCallbackParam, 10)
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/callback" {
+			time.Sleep(200 * time.Millisecond)
+			var params []*transport.HandleCallbackParam
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&params))
+			for _, p := range params {
+				callbacks <- p
+			}
+		}
+		_, _ = w.Write([]byte(`{"code":200}`))
+	}))
+	defer admin.Close()
+
+	port := freePort(t)
+	client := xxl.NewXxlClient(
+		option.WithAppName("test-app"),
+		option.WithEnableHttp(true),
+		option.WithClientPort(port),
+		option.WithAdminAddress(admin.URL),
+		option.WithAdminTimeout(time.Second),
+		option.WithLogBasePath(dir),
+	)
+
+	started := make(chan string, 2)
+	client.RegisterJob("drain_job", func(ctx context.Context) error {
+		started <- "drain_job"
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	})
+	client.RegisterJob("hang_job", func(ctx context.Context) error {
+		started <- "hang_job"
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	assert.NoError(t, client.Start())
+
+	run := func(jobId int32, logId int64, jobName string) {
+		body, _ := json.Marshal(&transport.TriggerParam{JobId: jobId, LogId: logId, ExecutorHandler: jobName})
+		resp, err := http.Post("http://127.0.0.1:"+strconv.Itoa(port)+"/run", "application/json", bytes.NewReader(body))
+		if assert.NoError(t, err) {
+			_ = resp.Body.Close()
+		}
+	}
+	run(1, 11, "drain_job")
+	run(2, 21, "hang_job")
+	<-started
+	<-started
+
+	// the drain_job completed, the hang_job is killed on ctx done
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	assert.Error(t, client.Shutdown(ctx))
+
+	// all callbacks are flushed before Shutdown return
+	results := make(map[int64]int32)
+	for len(callbacks) > 0 {
+		cb := <-callbacks
+		results[cb.LogId] = cb.ExecuteResult.Code
+	}
+	assert.Equal(t, map[int64]int32{11: http.StatusOK, 21: http.StatusInternalServerError}, results)
+}
+
+func TestXxlClient_Start_glueTypeShell(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xxl-client-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	origin, _ := handler.GetGlueType("GLUE_SHELL")
+	defer handler.RegisterGlueType(origin)
+
+	client := xxl.NewXxlClient(
+		option.WithClientPort(freePort(t)),
+		option.WithLogBasePath(dir),
+		option.WithGlueType(handler.GlueType{Name: "GLUE_SHELL", Suffix: ".sh", Bin: "sh"}),
+	)
+	client.WithConfigFunc(func(opts *option.ClientOptions) {
+		opts.Enable = false
+	})
+	assert.NoError(t, client.Start())
+	defer client.Shutdown(context.Background())
+
+	// the default ShellBin not override the GLUE_SHELL by WithGlueType
+	gt, _ := handler.GetGlueType("GLUE_SHELL")
+	assert.Equal(t, "sh", gt.Bin)
+}